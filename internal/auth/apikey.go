@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+)
+
+// Scopes limit what a personal API key or an OAuth client can do. Access
+// tokens issued to the user are not limited by scopes.
+const (
+	ScopeChirpsRead  = "chirps:read"
+	ScopeChirpsWrite = "chirps:write"
+	ScopeProfileRead = "profile:read"
+	// ScopeAccount covers managing the account itself: its email and
+	// password, sessions, 2FA and API keys.
+	ScopeAccount = "account"
+)
+
+var Scopes = []string{ScopeChirpsRead, ScopeChirpsWrite, ScopeProfileRead, ScopeAccount}
+
+func ValidScope(scope string) bool {
+	return slices.Contains(Scopes, scope)
+}
+
+// apiKeyMarker starts every personal API key, so leaked keys are easy to
+// recognize and tell apart from the admin and Polka keys.
+const apiKeyMarker = "chirpy_"
+
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// MakeAPIKey returns a new personal API key, formatted as
+// "chirpy_<prefix>_<secret>", along with its prefix. The prefix is stored
+// in the clear to look the key up; only a hash of the whole key is kept.
+func MakeAPIKey() (key, prefix string, err error) {
+	prefixBytes := make([]byte, 6)
+	_, err = rand.Read(prefixBytes)
+	if err != nil {
+		return "", "", err
+	}
+	secret := make([]byte, 32)
+	_, err = rand.Read(secret)
+	if err != nil {
+		return "", "", err
+	}
+
+	prefix = hex.EncodeToString(prefixBytes)
+	return apiKeyMarker + prefix + "_" + base64.RawURLEncoding.EncodeToString(secret), prefix, nil
+}
+
+// ParseAPIKeyPrefix returns the prefix of a personal API key.
+func ParseAPIKeyPrefix(key string) (string, error) {
+	rest, ok := strings.CutPrefix(key, apiKeyMarker)
+	if !ok {
+		return "", ErrInvalidAPIKey
+	}
+	prefix, secret, ok := strings.Cut(rest, "_")
+	if !ok || prefix == "" || secret == "" {
+		return "", ErrInvalidAPIKey
+	}
+	return prefix, nil
+}
+
+// HashAPIKey returns the digest stored for a personal API key.
+func HashAPIKey(key string) string {
+	return hashToken(key)
+}
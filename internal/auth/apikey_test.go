@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestParseAPIKeyPrefix(t *testing.T) {
+	key, prefix, err := MakeAPIKey()
+	if err != nil {
+		t.Fatalf("MakeAPIKey() error = %v", err)
+	}
+	if got, err := ParseAPIKeyPrefix(key); err != nil || got != prefix {
+		t.Errorf("ParseAPIKeyPrefix(%q) = %q, %v, want %q", key, got, err, prefix)
+	}
+
+	for _, key := range []string{"", "polka-key", "chirpy_", "chirpy_abc", "chirpy__secret", "chirpy_abc_"} {
+		if _, err := ParseAPIKeyPrefix(key); err == nil {
+			t.Errorf("ParseAPIKeyPrefix(%q) accepted an invalid key", key)
+		}
+	}
+}
+
+func TestPrincipalHasScope(t *testing.T) {
+	accessToken := Principal{UserID: uuid.New()}
+	if !accessToken.HasScope(ScopeAccount) {
+		t.Errorf("HasScope() = false for an access token")
+	}
+
+	apiKey := Principal{UserID: uuid.New(), APIKeyID: uuid.New(), Scopes: []string{ScopeChirpsWrite}}
+	if !apiKey.HasScope(ScopeChirpsWrite) {
+		t.Errorf("HasScope(%q) = false for a key holding it", ScopeChirpsWrite)
+	}
+	if apiKey.HasScope(ScopeAccount) {
+		t.Errorf("HasScope(%q) = true for a key without it", ScopeAccount)
+	}
+}
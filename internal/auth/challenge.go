@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// MakeTwoFactorChallengeToken signs a token proving that the user got their
+// password right. Together with a TOTP or recovery code it is exchanged for
+// an access token.
+func MakeTwoFactorChallengeToken(userID uuid.UUID, keys *KeySet, expiresIn time.Duration) (string, error) {
+	return keys.sign(jwt.RegisteredClaims{
+		Issuer:    string(TokenTypeTwoFactor),
+		Audience:  jwt.ClaimStrings{string(TokenTypeTwoFactor)},
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
+		Subject:   userID.String(),
+	})
+}
+
+// ValidateTwoFactorChallengeToken returns the user a challenge token was
+// issued for.
+func ValidateTwoFactorChallengeToken(tokenString string, keys *KeySet) (uuid.UUID, error) {
+	claims := jwt.RegisteredClaims{}
+	err := keys.parse(tokenString, &claims, TokenTypeTwoFactor)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+	return userID, nil
+}
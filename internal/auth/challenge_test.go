@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestValidateTwoFactorChallengeToken(t *testing.T) {
+	keys := NewHMACKeySet("secret")
+	userID := uuid.New()
+
+	token, err := MakeTwoFactorChallengeToken(userID, keys, time.Minute)
+	if err != nil {
+		t.Fatalf("MakeTwoFactorChallengeToken() error = %v", err)
+	}
+	gotUserID, err := ValidateTwoFactorChallengeToken(token, keys)
+	if err != nil || gotUserID != userID {
+		t.Errorf("ValidateTwoFactorChallengeToken() = %v, %v, want %v", gotUserID, err, userID)
+	}
+
+	if _, err := ParseJWT(token, keys); err == nil {
+		t.Errorf("ParseJWT() accepted a challenge token as an access token")
+	}
+
+	verification, _ := MakeEmailVerificationToken(userID, "a@example.com", keys, time.Hour)
+	if _, err := ValidateTwoFactorChallengeToken(verification, keys); err == nil {
+		t.Errorf("ValidateTwoFactorChallengeToken() accepted a verification token")
+	}
+
+	expired, _ := MakeTwoFactorChallengeToken(userID, keys, -time.Minute)
+	if _, err := ValidateTwoFactorChallengeToken(expired, keys); err == nil {
+		t.Errorf("ValidateTwoFactorChallengeToken() accepted an expired token")
+	}
+}
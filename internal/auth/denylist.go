@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RevocationStore reports whether an access token was revoked.
+type RevocationStore interface {
+	IsAccessTokenRevoked(ctx context.Context, id uuid.UUID) (bool, error)
+}
+
+// Denylist checks access token IDs against a RevocationStore, caching the
+// answers. A revocation is final, so revoked IDs are cached until the token
+// expires; tokens found valid are re-checked after ttl, which bounds how long
+// a revocation made by another instance takes to be seen here. Revocations
+// made through Revoke apply immediately.
+type Denylist struct {
+	store RevocationStore
+	ttl   time.Duration
+	now   func() time.Time
+
+	mu        sync.Mutex
+	entries   map[uuid.UUID]denylistEntry
+	nextSweep time.Time
+}
+
+type denylistEntry struct {
+	revoked bool
+	until   time.Time
+}
+
+func NewDenylist(store RevocationStore, ttl time.Duration) *Denylist {
+	return &Denylist{
+		store:   store,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[uuid.UUID]denylistEntry),
+	}
+}
+
+// IsRevoked reports whether the access token p was authenticated with has
+// been revoked.
+func (d *Denylist) IsRevoked(ctx context.Context, p Principal) (bool, error) {
+	id, err := uuid.Parse(p.TokenID)
+	if err != nil {
+		return false, err
+	}
+
+	d.mu.Lock()
+	entry, ok := d.entries[id]
+	d.mu.Unlock()
+	if ok && d.now().Before(entry.until) {
+		return entry.revoked, nil
+	}
+
+	revoked, err := d.store.IsAccessTokenRevoked(ctx, id)
+	if err != nil {
+		return false, err
+	}
+
+	entry = denylistEntry{revoked: revoked, until: d.now().Add(d.ttl)}
+	if revoked {
+		entry.until = p.ExpiresAt
+	}
+	d.set(id, entry)
+	return revoked, nil
+}
+
+// Revoke records in the cache that the token id, valid until expiresAt, was
+// revoked. The caller is responsible for persisting the revocation.
+func (d *Denylist) Revoke(id uuid.UUID, expiresAt time.Time) {
+	d.set(id, denylistEntry{revoked: true, until: expiresAt})
+}
+
+func (d *Denylist) set(id uuid.UUID, entry denylistEntry) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	if now.After(d.nextSweep) {
+		for id, entry := range d.entries {
+			if !now.Before(entry.until) {
+				delete(d.entries, id)
+			}
+		}
+		d.nextSweep = now.Add(d.ttl)
+	}
+	d.entries[id] = entry
+}
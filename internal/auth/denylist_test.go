@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type fakeRevocationStore struct {
+	revoked map[uuid.UUID]bool
+	calls   int
+}
+
+func (s *fakeRevocationStore) IsAccessTokenRevoked(ctx context.Context, id uuid.UUID) (bool, error) {
+	s.calls++
+	return s.revoked[id], nil
+}
+
+func TestDenylist(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeRevocationStore{revoked: make(map[uuid.UUID]bool)}
+	clock := time.Now()
+	d := NewDenylist(store, time.Minute)
+	d.now = func() time.Time { return clock }
+
+	principal := Principal{UserID: uuid.New(), TokenID: uuid.NewString(), ExpiresAt: clock.Add(time.Hour)}
+	tokenID := uuid.MustParse(principal.TokenID)
+
+	if revoked, err := d.IsRevoked(ctx, principal); err != nil || revoked {
+		t.Fatalf("IsRevoked() = %v, %v, want false", revoked, err)
+	}
+
+	// revoked by another instance: not seen until the cached answer expires
+	store.revoked[tokenID] = true
+	if revoked, _ := d.IsRevoked(ctx, principal); revoked {
+		t.Errorf("IsRevoked() = true before the cache entry expired")
+	}
+	if store.calls != 1 {
+		t.Errorf("store called %d times, want 1", store.calls)
+	}
+
+	clock = clock.Add(2 * time.Minute)
+	if revoked, _ := d.IsRevoked(ctx, principal); !revoked {
+		t.Errorf("IsRevoked() = false after the cache entry expired")
+	}
+
+	// revoked on this instance: applies immediately
+	other := Principal{UserID: uuid.New(), TokenID: uuid.NewString(), ExpiresAt: clock.Add(time.Hour)}
+	d.IsRevoked(ctx, other)
+	d.Revoke(uuid.MustParse(other.TokenID), other.ExpiresAt)
+	if revoked, _ := d.IsRevoked(ctx, other); !revoked {
+		t.Errorf("IsRevoked() = false right after Revoke()")
+	}
+
+	if _, err := d.IsRevoked(ctx, Principal{TokenID: "not-a-uuid"}); err == nil {
+		t.Errorf("IsRevoked() accepted a token without a valid ID")
+	}
+}
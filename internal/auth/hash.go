@@ -1,17 +1,197 @@
 package auth
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password hashing schemes. Stored hashes are told apart by their prefix.
+const (
+	SchemeBcrypt   = "bcrypt"
+	SchemeArgon2id = "argon2id"
+)
+
+const argon2idPrefix = "$argon2id$"
+
+var ErrPasswordMismatch = errors.New("password does not match")
+
+// Argon2Params are the argon2id cost settings. Memory is in KiB.
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2Params follow the OWASP recommendation for argon2id.
+var DefaultArgon2Params = Argon2Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// PasswordHasher hashes new passwords with Scheme and checks hashes made by
+// any supported scheme. Hashes made with another scheme or other costs can
+// be upgraded after a successful check, see NeedsRehash. The settings must
+// not change once the hasher is in use.
+type PasswordHasher struct {
+	Scheme     string
+	BcryptCost int
+	Argon2     Argon2Params
+
+	dummyOnce sync.Once
+	dummyHash string
+}
+
+func DefaultPasswordHasher() *PasswordHasher {
+	return &PasswordHasher{
+		Scheme:     SchemeArgon2id,
+		BcryptCost: bcrypt.DefaultCost,
+		Argon2:     DefaultArgon2Params,
+	}
+}
+
+// defaultHasher backs HashPassword and CheckPasswordHash.
+var defaultHasher = &PasswordHasher{Scheme: SchemeBcrypt, BcryptCost: bcrypt.DefaultCost}
 
 // HashPassword -
 func HashPassword(password string) (string, error) {
-	dat, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", err
-	}
-	return string(dat), nil
+	return defaultHasher.Hash(password)
 }
 
 // CheckPasswordHash -
 func CheckPasswordHash(password, hash string) error {
+	return defaultHasher.Check(password, hash)
+}
+
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	if password == "" {
+		return "", errors.New("password is empty")
+	}
+
+	switch h.Scheme {
+	case SchemeBcrypt:
+		dat, err := bcrypt.GenerateFromPassword([]byte(password), h.BcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(dat), nil
+	case SchemeArgon2id:
+		return hashArgon2id(password, h.Argon2)
+	default:
+		return "", fmt.Errorf("unknown password hashing scheme %q", h.Scheme)
+	}
+}
+
+// Check reports whether password matches hash, whatever scheme made it.
+func (h *PasswordHasher) Check(password, hash string) error {
+	if strings.HasPrefix(hash, argon2idPrefix) {
+		return checkArgon2id(password, hash)
+	}
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 }
+
+// CheckDummy checks password against a hash that matches nothing, made with
+// the current settings. Calling it when there is no user to check against
+// makes the failure take as long as a wrong password.
+func (h *PasswordHasher) CheckDummy(password string) {
+	h.dummyOnce.Do(func() {
+		dummy := make([]byte, 32)
+		rand.Read(dummy)
+		h.dummyHash, _ = h.Hash(base64.RawStdEncoding.EncodeToString(dummy))
+	})
+	h.Check(password, h.dummyHash)
+}
+
+// NeedsRehash reports whether hash was made with another scheme or other
+// costs than h would use now.
+func (h *PasswordHasher) NeedsRehash(hash string) bool {
+	switch h.Scheme {
+	case SchemeBcrypt:
+		cost, err := bcrypt.Cost([]byte(hash))
+		return err != nil || cost != h.BcryptCost
+	case SchemeArgon2id:
+		params, _, _, err := decodeArgon2idHash(hash)
+		return err != nil || params != h.Argon2
+	default:
+		return false
+	}
+}
+
+// hashArgon2id encodes the hash in the PHC string format also used by the
+// reference implementation:
+//
+//	$argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>
+func hashArgon2id(password string, params Argon2Params) (string, error) {
+	salt := make([]byte, params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		params.Memory,
+		params.Iterations,
+		params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func checkArgon2id(password, hash string) error {
+	params, salt, key, err := decodeArgon2idHash(hash)
+	if err != nil {
+		return err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return ErrPasswordMismatch
+	}
+	return nil
+}
+
+func decodeArgon2idHash(hash string) (Argon2Params, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || "$"+parts[1]+"$" != argon2idPrefix {
+		return Argon2Params{}, nil, nil, errors.New("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("invalid argon2id hash: %w", err)
+	}
+	if version != argon2.Version {
+		return Argon2Params{}, nil, nil, fmt.Errorf("unsupported argon2 version %d", version)
+	}
+
+	params := Argon2Params{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("invalid argon2id hash: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("invalid argon2id salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("invalid argon2id key: %w", err)
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
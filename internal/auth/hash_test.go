@@ -56,3 +56,97 @@ func TestCheckPasswordHash(t *testing.T) {
 		})
 	}
 }
+
+// testArgon2Params keep the tests fast.
+var testArgon2Params = Argon2Params{
+	Memory:      1024,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func TestPasswordHasher(t *testing.T) {
+	argon := &PasswordHasher{Scheme: SchemeArgon2id, BcryptCost: 4, Argon2: testArgon2Params}
+	bcryptHasher := &PasswordHasher{Scheme: SchemeBcrypt, BcryptCost: 4}
+
+	argonHash, err := argon.Hash("correctPassword123!")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	bcryptHash, err := bcryptHasher.Hash("correctPassword123!")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		hasher     *PasswordHasher
+		password   string
+		hash       string
+		wantErr    bool
+		wantRehash bool
+	}{
+		{
+			name:     "argon2id hash checked by argon2id hasher",
+			hasher:   argon,
+			password: "correctPassword123!",
+			hash:     argonHash,
+		},
+		{
+			name:     "wrong password against argon2id hash",
+			hasher:   argon,
+			password: "wrongPassword",
+			hash:     argonHash,
+			wantErr:  true,
+		},
+		{
+			name:       "bcrypt hash upgraded to argon2id",
+			hasher:     argon,
+			password:   "correctPassword123!",
+			hash:       bcryptHash,
+			wantRehash: true,
+		},
+		{
+			name:       "argon2id hash downgraded to bcrypt",
+			hasher:     bcryptHasher,
+			password:   "correctPassword123!",
+			hash:       argonHash,
+			wantRehash: true,
+		},
+		{
+			name:       "bcrypt cost raised",
+			hasher:     &PasswordHasher{Scheme: SchemeBcrypt, BcryptCost: 5},
+			password:   "correctPassword123!",
+			hash:       bcryptHash,
+			wantRehash: true,
+		},
+		{
+			name:       "argon2id params changed",
+			hasher:     &PasswordHasher{Scheme: SchemeArgon2id, Argon2: DefaultArgon2Params},
+			password:   "correctPassword123!",
+			hash:       argonHash,
+			wantRehash: true,
+		},
+		{
+			name:       "malformed argon2id hash",
+			hasher:     argon,
+			password:   "correctPassword123!",
+			hash:       "$argon2id$v=19$m=1024$salt$key",
+			wantErr:    true,
+			wantRehash: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.hasher.Check(tt.password, tt.hash)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := tt.hasher.NeedsRehash(tt.hash); got != tt.wantRehash {
+				t.Errorf("NeedsRehash() = %v, want %v", got, tt.wantRehash)
+			}
+		})
+	}
+}
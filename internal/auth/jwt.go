@@ -2,8 +2,8 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
-	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -16,50 +16,112 @@ import (
 type TokenType string
 
 const (
-	TokenTypeAccess TokenType = "chirpy-access"
+	TokenTypeAccess            TokenType = "chirpy-access"
+	TokenTypeEmailVerification TokenType = "chirpy-email-verification"
+	TokenTypeTwoFactor         TokenType = "chirpy-2fa-challenge"
+	TokenTypeOIDCState         TokenType = "chirpy-oidc-state"
 )
 
-func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
-		Issuer:    string(TokenTypeAccess),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
-		Subject:   userID.String(),
-	})
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
 
-	return token.SignedString([]byte(tokenSecret))
+// Claims are the claims carried by a Chirpy access token. Tokens issued to
+// OAuth clients also carry the client ID and the space separated scopes the
+// user granted it.
+type Claims struct {
+	jwt.RegisteredClaims
+	Role     string `json:"role,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
 }
 
-func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
-	claimsStruct := jwt.RegisteredClaims{}
-	token, err := jwt.ParseWithClaims(
-		tokenString,
-		&claimsStruct,
-		func(token *jwt.Token) (interface{}, error) { return []byte(tokenSecret), nil },
-	)
+// AccessToken is a signed access token along with the claims needed to
+// revoke it before it expires.
+type AccessToken struct {
+	Token     string
+	ID        uuid.UUID
+	ExpiresAt time.Time
+}
+
+// MakeAccessToken signs an access token for userID with a unique ID (jti).
+func MakeAccessToken(userID uuid.UUID, role string, keys *KeySet, expiresIn time.Duration) (AccessToken, error) {
+	return makeAccessToken(userID, Claims{Role: role}, keys, expiresIn)
+}
 
+// MakeClientAccessToken signs an access token that lets the OAuth client
+// clientID act for userID within scopes.
+func MakeClientAccessToken(userID, clientID uuid.UUID, scopes []string, keys *KeySet, expiresIn time.Duration) (AccessToken, error) {
+	return makeAccessToken(userID, Claims{
+		Scope:    strings.Join(scopes, " "),
+		ClientID: clientID.String(),
+	}, keys, expiresIn)
+}
+
+// makeAccessToken fills in the registered claims of claims and signs them.
+func makeAccessToken(userID uuid.UUID, claims Claims, keys *KeySet, expiresIn time.Duration) (AccessToken, error) {
+	now := time.Now()
+	accessToken := AccessToken{
+		ID:        uuid.New(),
+		ExpiresAt: now.Add(expiresIn),
+	}
+
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		Issuer:    string(TokenTypeAccess),
+		Audience:  jwt.ClaimStrings{string(TokenTypeAccess)},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(accessToken.ExpiresAt),
+		Subject:   userID.String(),
+		ID:        accessToken.ID.String(),
+	}
+	token, err := keys.sign(claims)
 	if err != nil {
-		return uuid.Nil, err
+		return AccessToken{}, err
 	}
+	accessToken.Token = token
+	return accessToken, nil
+}
 
-	userIDString, err := token.Claims.GetSubject()
+func MakeJWT(userID uuid.UUID, role string, keys *KeySet, expiresIn time.Duration) (string, error) {
+	accessToken, err := MakeAccessToken(userID, role, keys, expiresIn)
 	if err != nil {
-		return uuid.Nil, err
+		return "", err
 	}
+	return accessToken.Token, nil
+}
 
-	issuer, err := token.Claims.GetIssuer()
+// ParseJWT validates an access token and returns its claims.
+func ParseJWT(tokenString string, keys *KeySet) (*Claims, error) {
+	claimsStruct := Claims{}
+	err := keys.parse(tokenString, &claimsStruct, TokenTypeAccess)
 	if err != nil {
-		return uuid.Nil, err
+		return nil, err
 	}
-	if issuer != string(TokenTypeAccess) {
-		return uuid.Nil, errors.New("invalid issuer")
+
+	// Principal relies on the subject being a valid user ID
+	if _, err := uuid.Parse(claimsStruct.Subject); err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+	// and revocation on every token having an ID
+	if _, err := uuid.Parse(claimsStruct.ID); err != nil {
+		return nil, fmt.Errorf("invalid token ID: %w", err)
 	}
+	// and on client tokens naming a valid client
+	if claimsStruct.ClientID != "" {
+		if _, err := uuid.Parse(claimsStruct.ClientID); err != nil {
+			return nil, fmt.Errorf("invalid client ID: %w", err)
+		}
+	}
+	return &claimsStruct, nil
+}
 
-	id, err := uuid.Parse(userIDString)
+func ValidateJWT(tokenString string, keys *KeySet) (uuid.UUID, error) {
+	claims, err := ParseJWT(tokenString, keys)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("invalid user ID: %w", err)
+		return uuid.Nil, err
 	}
-	return id, nil
+	return uuid.MustParse(claims.Subject), nil
 }
 
 func GetBearerToken(headers http.Header) (string, error) {
@@ -77,10 +139,24 @@ func GetBearerToken(headers http.Header) (string, error) {
 
 func MakeRefreshToken() (string, error) {
 	tokenBytes := make([]byte, 256)
-	rand.Read(tokenBytes)
+	_, err := rand.Read(tokenBytes)
+	if err != nil {
+		return "", err
+	}
 	return hex.EncodeToString(tokenBytes), nil
 }
 
+// HashRefreshToken returns the hex SHA-256 digest of a refresh token. Only
+// the digest is stored, so a leaked table can't be used to hijack sessions.
+func HashRefreshToken(token string) string {
+	return hashToken(token)
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 func GetAPIKey(headers http.Header) (string, error) {
 	token := headers.Get("Authorization")
 	if token == "" {
@@ -6,12 +6,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
 func TestValidateJWT(t *testing.T) {
 	userID := uuid.New()
-	validToken, _ := MakeJWT(userID, "secret", time.Hour)
+	validToken, _ := MakeJWT(userID, RoleUser, NewHMACKeySet("secret"), time.Hour)
 
 	tests := []struct {
 		name        string
@@ -45,7 +46,7 @@ func TestValidateJWT(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotUserID, err := ValidateJWT(tt.tokenString, tt.tokenSecret)
+			gotUserID, err := ValidateJWT(tt.tokenString, NewHMACKeySet(tt.tokenSecret))
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ValidateJWT() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -57,6 +58,26 @@ func TestValidateJWT(t *testing.T) {
 	}
 }
 
+func TestParseJWTRole(t *testing.T) {
+	userID := uuid.New()
+
+	for _, role := range []string{RoleUser, RoleAdmin} {
+		t.Run(role, func(t *testing.T) {
+			token, err := MakeJWT(userID, role, NewHMACKeySet("secret"), time.Hour)
+			if err != nil {
+				t.Fatalf("MakeJWT() error = %v", err)
+			}
+			claims, err := ParseJWT(token, NewHMACKeySet("secret"))
+			if err != nil {
+				t.Fatalf("ParseJWT() error = %v", err)
+			}
+			if claims.Role != role {
+				t.Errorf("ParseJWT() role = %q, want %q", claims.Role, role)
+			}
+		})
+	}
+}
+
 func TestGetBearerToken(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -119,3 +140,62 @@ func TestGetBearerToken(t *testing.T) {
 		})
 	}
 }
+
+func TestTokenKindsAreNotInterchangeable(t *testing.T) {
+	keys := NewHMACKeySet("secret")
+	userID := uuid.New()
+
+	access, _ := MakeJWT(userID, RoleUser, keys, time.Hour)
+	verification, _ := MakeEmailVerificationToken(userID, "a@example.com", keys, time.Hour)
+	challenge, _ := MakeTwoFactorChallengeToken(userID, keys, time.Hour)
+	state, _ := MakeOIDCStateToken(OIDCState{Provider: "test", State: "state"}, keys, time.Hour)
+	tokens := map[TokenType]string{
+		TokenTypeAccess:            access,
+		TokenTypeEmailVerification: verification,
+		TokenTypeTwoFactor:         challenge,
+		TokenTypeOIDCState:         state,
+	}
+
+	validators := map[TokenType]func(string) error{
+		TokenTypeAccess: func(token string) error {
+			_, err := ParseJWT(token, keys)
+			return err
+		},
+		TokenTypeEmailVerification: func(token string) error {
+			_, _, err := ValidateEmailVerificationToken(token, keys)
+			return err
+		},
+		TokenTypeTwoFactor: func(token string) error {
+			_, err := ValidateTwoFactorChallengeToken(token, keys)
+			return err
+		},
+		TokenTypeOIDCState: func(token string) error {
+			_, err := ValidateOIDCStateToken(token, keys)
+			return err
+		},
+	}
+
+	for tokenType, token := range tokens {
+		for validatorType, validate := range validators {
+			err := validate(token)
+			if tokenType == validatorType && err != nil {
+				t.Errorf("validating a %s token as itself: error = %v", tokenType, err)
+			}
+			if tokenType != validatorType && err == nil {
+				t.Errorf("validating a %s token as a %s token succeeded, want an error", tokenType, validatorType)
+			}
+		}
+	}
+
+	// the issuer alone isn't enough, the audience has to match too
+	forged, _ := keys.sign(Claims{RegisteredClaims: jwt.RegisteredClaims{
+		Issuer:    string(TokenTypeAccess),
+		Audience:  jwt.ClaimStrings{string(TokenTypeTwoFactor)},
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		Subject:   userID.String(),
+		ID:        uuid.NewString(),
+	}})
+	if _, err := ParseJWT(forged, keys); err == nil {
+		t.Error("ParseJWT() of a token for another audience succeeded, want an error")
+	}
+}
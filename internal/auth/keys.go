@@ -0,0 +1,247 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeySet holds the key used to sign access tokens and every key accepted when
+// verifying them. Keeping the previous public keys in the set during a
+// rotation window lets tokens signed before the rotation stay valid.
+type KeySet struct {
+	signing   signingKey
+	verifying map[string]verificationKey
+}
+
+type signingKey struct {
+	kid    string
+	method jwt.SigningMethod
+	key    interface{}
+}
+
+type verificationKey struct {
+	method jwt.SigningMethod
+	key    interface{}
+	jwk    *JWK
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is the document served at /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewHMACKeySet returns a KeySet that signs and verifies with HS256 and a
+// shared secret. Symmetric keys are never published in the JWKS.
+func NewHMACKeySet(secret string) *KeySet {
+	key := []byte(secret)
+	return &KeySet{
+		signing: signingKey{method: jwt.SigningMethodHS256, key: key},
+		verifying: map[string]verificationKey{
+			"": {method: jwt.SigningMethodHS256, key: key},
+		},
+	}
+}
+
+// NewKeySet returns a KeySet signing with signer, which must be an RSA
+// (RS256) or Ed25519 (EdDSA) private key. Tokens signed by signer or by any of
+// the extra verification keys are accepted.
+func NewKeySet(signer crypto.Signer, verification ...crypto.PublicKey) (*KeySet, error) {
+	ks := &KeySet{verifying: make(map[string]verificationKey)}
+
+	signingVerification, err := ks.addVerificationKey(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	ks.signing = signingKey{
+		kid:    signingVerification.jwk.Kid,
+		method: signingVerification.method,
+		key:    signer,
+	}
+
+	for _, publicKey := range verification {
+		if _, err := ks.addVerificationKey(publicKey); err != nil {
+			return nil, err
+		}
+	}
+	return ks, nil
+}
+
+// LoadKeySet reads the signing key and the extra verification keys from PEM
+// files. Verification files may hold either public or private keys.
+func LoadKeySet(signingKeyFile string, verificationKeyFiles []string) (*KeySet, error) {
+	privateKey, err := readPEMKey(signingKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%s: not a private key", signingKeyFile)
+	}
+
+	var verification []crypto.PublicKey
+	for _, path := range verificationKeyFiles {
+		key, err := readPEMKey(path)
+		if err != nil {
+			return nil, err
+		}
+		if signer, ok := key.(crypto.Signer); ok {
+			key = signer.Public()
+		}
+		verification = append(verification, key)
+	}
+
+	return NewKeySet(signer, verification...)
+}
+
+func readPEMKey(path string) (interface{}, error) {
+	dat, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(dat)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data found", path)
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%s: unsupported PEM block %q", path, block.Type)
+	}
+}
+
+func (ks *KeySet) addVerificationKey(publicKey crypto.PublicKey) (verificationKey, error) {
+	var vk verificationKey
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		vk = verificationKey{
+			method: jwt.SigningMethodRS256,
+			key:    key,
+			jwk: &JWK{
+				Kty: "RSA",
+				Alg: jwt.SigningMethodRS256.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			},
+		}
+	case ed25519.PublicKey:
+		vk = verificationKey{
+			method: jwt.SigningMethodEdDSA,
+			key:    key,
+			jwk: &JWK{
+				Kty: "OKP",
+				Alg: jwt.SigningMethodEdDSA.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(key),
+			},
+		}
+	default:
+		return verificationKey{}, fmt.Errorf("unsupported key type %T: use an RSA or Ed25519 key", publicKey)
+	}
+
+	vk.jwk.Use = "sig"
+	vk.jwk.Kid = vk.jwk.thumbprint()
+	ks.verifying[vk.jwk.Kid] = vk
+	return vk, nil
+}
+
+// thumbprint computes the RFC 7638 JWK thumbprint, used as the key ID so it
+// is stable across restarts and identical on every instance.
+func (k JWK) thumbprint() string {
+	var required interface{}
+	switch k.Kty {
+	case "RSA":
+		required = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{k.E, k.Kty, k.N}
+	case "OKP":
+		required = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{k.Crv, k.Kty, k.X}
+	}
+
+	dat, _ := json.Marshal(required)
+	sum := sha256.Sum256(dat)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// JWKS returns the public verification keys.
+func (ks *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for _, vk := range ks.verifying {
+		if vk.jwk != nil {
+			jwks.Keys = append(jwks.Keys, *vk.jwk)
+		}
+	}
+	return jwks
+}
+
+// sign signs token with the signing key, setting its kid header.
+func (ks *KeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.signing.method, claims)
+	if ks.signing.kid != "" {
+		token.Header["kid"] = ks.signing.kid
+	}
+	return token.SignedString(ks.signing.key)
+}
+
+// parse verifies tokenString and fills in claims. Every kind of token is
+// signed with the same keys, so the issuer and audience have to name typ, or
+// a token of one kind could be passed off as another.
+func (ks *KeySet) parse(tokenString string, claims jwt.Claims, typ TokenType) error {
+	_, err := jwt.ParseWithClaims(tokenString, claims, ks.keyFunc,
+		jwt.WithIssuer(string(typ)),
+		jwt.WithAudience(string(typ)),
+	)
+	return err
+}
+
+// keyFunc picks the verification key named by the token's kid header and
+// makes sure the token uses that key's algorithm.
+func (ks *KeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	vk, ok := ks.verifying[kid]
+	if !ok {
+		return nil, errors.New("unknown signing key")
+	}
+	if token.Method.Alg() != vk.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+	return vk.key, nil
+}
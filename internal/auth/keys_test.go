@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func writePEM(t *testing.T, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func writePrivateKey(t *testing.T, key crypto.Signer) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writePEM(t, "PRIVATE KEY", der)
+}
+
+func writePublicKey(t *testing.T, key crypto.PublicKey) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return writePEM(t, "PUBLIC KEY", der)
+}
+
+func TestLoadKeySet(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		key     crypto.Signer
+		wantKty string
+		wantAlg string
+	}{
+		{name: "RSA", key: rsaKey, wantKty: "RSA", wantAlg: "RS256"},
+		{name: "Ed25519", key: edKey, wantKty: "OKP", wantAlg: "EdDSA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := LoadKeySet(writePrivateKey(t, tt.key), nil)
+			if err != nil {
+				t.Fatalf("LoadKeySet() error = %v", err)
+			}
+
+			userID := uuid.New()
+			token, err := MakeJWT(userID, RoleUser, keys, time.Hour)
+			if err != nil {
+				t.Fatalf("MakeJWT() error = %v", err)
+			}
+			gotUserID, err := ValidateJWT(token, keys)
+			if err != nil {
+				t.Fatalf("ValidateJWT() error = %v", err)
+			}
+			if gotUserID != userID {
+				t.Errorf("ValidateJWT() = %v, want %v", gotUserID, userID)
+			}
+
+			jwks := keys.JWKS()
+			if len(jwks.Keys) != 1 {
+				t.Fatalf("JWKS() has %d keys, want 1", len(jwks.Keys))
+			}
+			jwk := jwks.Keys[0]
+			if jwk.Kty != tt.wantKty || jwk.Alg != tt.wantAlg || jwk.Use != "sig" || jwk.Kid == "" {
+				t.Errorf("JWKS() key = %+v, want kty %s alg %s", jwk, tt.wantKty, tt.wantAlg)
+			}
+		})
+	}
+}
+
+func TestKeySetRotation(t *testing.T) {
+	_, oldKey, _ := ed25519.GenerateKey(rand.Reader)
+	_, newKey, _ := ed25519.GenerateKey(rand.Reader)
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	oldKeys, err := NewKeySet(oldKey)
+	if err != nil {
+		t.Fatalf("NewKeySet() error = %v", err)
+	}
+	oldToken, _ := MakeJWT(uuid.New(), RoleUser, oldKeys, time.Hour)
+
+	rotated, err := LoadKeySet(writePrivateKey(t, newKey), []string{writePublicKey(t, oldKey.Public())})
+	if err != nil {
+		t.Fatalf("LoadKeySet() error = %v", err)
+	}
+	if len(rotated.JWKS().Keys) != 2 {
+		t.Errorf("JWKS() has %d keys, want 2", len(rotated.JWKS().Keys))
+	}
+	if _, err := ValidateJWT(oldToken, rotated); err != nil {
+		t.Errorf("ValidateJWT() rejected a token signed by the previous key: %v", err)
+	}
+
+	otherKeys, _ := NewKeySet(otherKey)
+	otherToken, _ := MakeJWT(uuid.New(), RoleUser, otherKeys, time.Hour)
+	if _, err := ValidateJWT(otherToken, rotated); err == nil {
+		t.Errorf("ValidateJWT() accepted a token signed by an unknown key")
+	}
+
+	hmacToken, _ := MakeJWT(uuid.New(), RoleUser, NewHMACKeySet("secret"), time.Hour)
+	if _, err := ValidateJWT(hmacToken, rotated); err == nil {
+		t.Errorf("ValidateJWT() accepted an HS256 token without a kid")
+	}
+}
+
+func TestHMACKeySetJWKS(t *testing.T) {
+	if keys := NewHMACKeySet("secret").JWKS().Keys; len(keys) != 0 {
+		t.Errorf("JWKS() published %d symmetric keys, want none", len(keys))
+	}
+}
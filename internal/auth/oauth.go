@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// OAuthScopes are the scopes users can grant to OAuth clients. Managing the
+// account itself is left to the user.
+var OAuthScopes = []string{ScopeChirpsRead, ScopeChirpsWrite, ScopeProfileRead}
+
+var ErrInvalidScope = errors.New("invalid scope")
+
+// ParseOAuthScope parses the space separated scope parameter of an OAuth
+// request into a sorted list of distinct scopes.
+func ParseOAuthScope(scope string) ([]string, error) {
+	scopes := strings.Fields(scope)
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("%w: request at least one of %s", ErrInvalidScope, strings.Join(OAuthScopes, ", "))
+	}
+	for _, s := range scopes {
+		if !slices.Contains(OAuthScopes, s) {
+			return nil, fmt.Errorf("%w: unknown scope %q", ErrInvalidScope, s)
+		}
+	}
+	slices.Sort(scopes)
+	return slices.Compact(scopes), nil
+}
+
+// MakeOAuthSecret returns a random value for client secrets and
+// authorization codes. Only its hash, see HashOAuthSecret, is stored.
+func MakeOAuthSecret() (string, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+func HashOAuthSecret(secret string) string {
+	return hashToken(secret)
+}
+
+// ValidCodeChallenge reports whether challenge looks like an S256 PKCE code
+// challenge: the unpadded base64url encoding of a SHA-256 digest.
+func ValidCodeChallenge(challenge string) bool {
+	digest, err := base64.RawURLEncoding.DecodeString(challenge)
+	return err == nil && len(digest) == sha256.Size
+}
+
+// VerifyPKCE reports whether verifier is the S256 PKCE code verifier of
+// challenge, see RFC 7636.
+func VerifyPKCE(verifier, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	for _, c := range verifier {
+		if !isUnreserved(c) {
+			return false
+		}
+	}
+	return subtle.ConstantTimeCompare([]byte(PKCEChallenge(verifier)), []byte(challenge)) == 1
+}
+
+// PKCEChallenge returns the S256 code challenge of verifier.
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// isUnreserved reports whether c may appear in a code verifier.
+func isUnreserved(c rune) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestVerifyPKCE(t *testing.T) {
+	// the example of RFC 7636, appendix B
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	challenge := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+
+	if !ValidCodeChallenge(challenge) {
+		t.Errorf("ValidCodeChallenge(%q) = false", challenge)
+	}
+	if !VerifyPKCE(verifier, challenge) {
+		t.Errorf("VerifyPKCE() = false for the RFC 7636 example")
+	}
+
+	for _, verifier := range []string{"", "short", verifier[:42], verifier + "!"} {
+		if VerifyPKCE(verifier, challenge) {
+			t.Errorf("VerifyPKCE(%q) accepted an invalid verifier", verifier)
+		}
+	}
+	if ValidCodeChallenge(verifier + "x") {
+		t.Errorf("ValidCodeChallenge() accepted a value that is not a SHA-256 digest")
+	}
+}
+
+func TestParseOAuthScope(t *testing.T) {
+	scopes, err := ParseOAuthScope("profile:read chirps:write  profile:read")
+	if err != nil {
+		t.Fatalf("ParseOAuthScope() error = %v", err)
+	}
+	if want := []string{ScopeChirpsWrite, ScopeProfileRead}; !slices.Equal(scopes, want) {
+		t.Errorf("ParseOAuthScope() = %v, want %v", scopes, want)
+	}
+
+	for _, scope := range []string{"", " ", ScopeAccount, "chirps:write bogus"} {
+		if _, err := ParseOAuthScope(scope); !errors.Is(err, ErrInvalidScope) {
+			t.Errorf("ParseOAuthScope(%q) error = %v, want ErrInvalidScope", scope, err)
+		}
+	}
+}
+
+func TestClientAccessTokenPrincipal(t *testing.T) {
+	keys := NewHMACKeySet("secret")
+	userID, clientID := uuid.New(), uuid.New()
+
+	token, err := MakeClientAccessToken(userID, clientID, []string{ScopeChirpsWrite}, keys, time.Hour)
+	if err != nil {
+		t.Fatalf("MakeClientAccessToken() error = %v", err)
+	}
+	claims, err := ParseJWT(token.Token, keys)
+	if err != nil {
+		t.Fatalf("ParseJWT() error = %v", err)
+	}
+
+	principal := claims.Principal()
+	if principal.UserID != userID || principal.ClientID != clientID || !principal.IsScoped() {
+		t.Errorf("Principal() = %+v, want user %v acting through client %v", principal, userID, clientID)
+	}
+	if !principal.HasScope(ScopeChirpsWrite) || principal.HasScope(ScopeAccount) {
+		t.Errorf("Principal() scopes = %v, want only %q", principal.Scopes, ScopeChirpsWrite)
+	}
+}
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OIDCState is what Chirpy remembers about a login with an external
+// identity provider while the user is away at the provider.
+type OIDCState struct {
+	Provider     string `json:"provider"`
+	State        string `json:"state"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+}
+
+type oidcStateClaims struct {
+	jwt.RegisteredClaims
+	OIDCState
+}
+
+// MakeOIDCStateToken signs state so it can be kept in the user's browser
+// until the provider sends them back.
+func MakeOIDCStateToken(state OIDCState, keys *KeySet, expiresIn time.Duration) (string, error) {
+	return keys.sign(oidcStateClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    string(TokenTypeOIDCState),
+			Audience:  jwt.ClaimStrings{string(TokenTypeOIDCState)},
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
+		},
+		OIDCState: state,
+	})
+}
+
+func ValidateOIDCStateToken(tokenString string, keys *KeySet) (OIDCState, error) {
+	claims := oidcStateClaims{}
+	err := keys.parse(tokenString, &claims, TokenTypeOIDCState)
+	if err != nil {
+		return OIDCState{}, err
+	}
+	return claims.OIDCState, nil
+}
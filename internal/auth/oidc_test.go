@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestValidateOIDCStateToken(t *testing.T) {
+	keys := NewHMACKeySet("secret")
+	state := OIDCState{Provider: "example", State: "s", Nonce: "n", CodeVerifier: "v"}
+
+	token, err := MakeOIDCStateToken(state, keys, time.Minute)
+	if err != nil {
+		t.Fatalf("MakeOIDCStateToken() error = %v", err)
+	}
+	got, err := ValidateOIDCStateToken(token, keys)
+	if err != nil || got != state {
+		t.Errorf("ValidateOIDCStateToken() = %+v, %v, want %+v", got, err, state)
+	}
+
+	if _, err := ParseJWT(token, keys); err == nil {
+		t.Errorf("ParseJWT() accepted a state token as an access token")
+	}
+
+	challenge, _ := MakeTwoFactorChallengeToken(uuid.New(), keys, time.Minute)
+	if _, err := ValidateOIDCStateToken(challenge, keys); err == nil {
+		t.Errorf("ValidateOIDCStateToken() accepted a challenge token")
+	}
+
+	expired, _ := MakeOIDCStateToken(state, keys, -time.Minute)
+	if _, err := ValidateOIDCStateToken(expired, keys); err == nil {
+		t.Errorf("ValidateOIDCStateToken() accepted an expired token")
+	}
+}
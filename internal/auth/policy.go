@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// bcryptMaxLength is the number of bytes bcrypt looks at; anything past it
+// would be silently ignored.
+const bcryptMaxLength = 72
+
+// Character classes a PasswordPolicy can require.
+const (
+	ClassUpper  = "upper"
+	ClassLower  = "lower"
+	ClassDigit  = "digit"
+	ClassSymbol = "symbol"
+)
+
+// PasswordPolicy decides which passwords users may choose. It is usually
+// loaded from a JSON file:
+//
+//	{
+//	  "min_length": 12,
+//	  "max_length": 72,
+//	  "require": ["upper", "lower", "digit"],
+//	  "breached_file": "breached.txt"
+//	}
+type PasswordPolicy struct {
+	// MinLength is counted in characters.
+	MinLength int `json:"min_length"`
+	// MaxLength is counted in bytes and can't exceed 72.
+	MaxLength int      `json:"max_length"`
+	Require   []string `json:"require"`
+	// BreachedFile lists the hex SHA-1 digests of known-breached passwords,
+	// one per line. Lines in the "HASH:COUNT" format used by breach corpora
+	// are accepted.
+	BreachedFile string `json:"breached_file"`
+
+	breached map[string]struct{}
+}
+
+// PolicyViolation is a rule a password failed.
+type PolicyViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// DefaultPasswordPolicy is used when no policy file is configured.
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{MinLength: 8, MaxLength: bcryptMaxLength}
+}
+
+func LoadPasswordPolicy(path string) (PasswordPolicy, error) {
+	dat, err := os.ReadFile(path)
+	if err != nil {
+		return PasswordPolicy{}, err
+	}
+
+	policy := DefaultPasswordPolicy()
+	if err := json.Unmarshal(dat, &policy); err != nil {
+		return PasswordPolicy{}, fmt.Errorf("parsing password policy %s: %w", path, err)
+	}
+
+	if policy.MaxLength <= 0 || policy.MaxLength > bcryptMaxLength {
+		return PasswordPolicy{}, fmt.Errorf("password policy %s: max_length must be between 1 and %d", path, bcryptMaxLength)
+	}
+	if policy.MinLength > policy.MaxLength {
+		return PasswordPolicy{}, fmt.Errorf("password policy %s: min_length is greater than max_length", path)
+	}
+	for _, class := range policy.Require {
+		if _, ok := classChecks[class]; !ok {
+			return PasswordPolicy{}, fmt.Errorf("password policy %s: unknown character class %q", path, class)
+		}
+	}
+
+	if policy.BreachedFile != "" {
+		policy.breached, err = loadBreachedHashes(policy.BreachedFile)
+		if err != nil {
+			return PasswordPolicy{}, err
+		}
+	}
+	return policy, nil
+}
+
+func loadBreachedHashes(path string) (map[string]struct{}, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hashes := make(map[string]struct{})
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		hash, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if hash == "" || strings.HasPrefix(hash, "#") {
+			continue
+		}
+		hashes[strings.ToUpper(hash)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading breached passwords %s: %w", path, err)
+	}
+	return hashes, nil
+}
+
+var classChecks = map[string]struct {
+	matches func(rune) bool
+	message string
+}{
+	ClassUpper:  {unicode.IsUpper, "must contain an uppercase letter"},
+	ClassLower:  {unicode.IsLower, "must contain a lowercase letter"},
+	ClassDigit:  {unicode.IsDigit, "must contain a digit"},
+	ClassSymbol: {func(r rune) bool { return unicode.IsPunct(r) || unicode.IsSymbol(r) }, "must contain a symbol"},
+}
+
+// Check returns every rule password breaks, or nothing if it is acceptable.
+func (p PasswordPolicy) Check(password string) []PolicyViolation {
+	var violations []PolicyViolation
+
+	if length := utf8.RuneCountInString(password); length < p.MinLength {
+		violations = append(violations, PolicyViolation{
+			Rule:    "min_length",
+			Message: fmt.Sprintf("must be at least %d characters long", p.MinLength),
+		})
+	}
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		violations = append(violations, PolicyViolation{
+			Rule:    "max_length",
+			Message: fmt.Sprintf("must be at most %d bytes long", p.MaxLength),
+		})
+	}
+
+	for _, class := range p.Require {
+		check := classChecks[class]
+		if !strings.ContainsFunc(password, check.matches) {
+			violations = append(violations, PolicyViolation{Rule: class, Message: check.message})
+		}
+	}
+
+	if p.breached != nil {
+		sum := sha1.Sum([]byte(password))
+		if _, ok := p.breached[strings.ToUpper(hex.EncodeToString(sum[:]))]; ok {
+			violations = append(violations, PolicyViolation{
+				Rule:    "breached",
+				Message: "appears in a known data breach",
+			})
+		}
+	}
+	return violations
+}
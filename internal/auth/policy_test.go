@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	dir := t.TempDir()
+	breachedFile := filepath.Join(dir, "breached.txt")
+	// SHA-1 of "Password1!", in the HASH:COUNT format of breach corpora
+	os.WriteFile(breachedFile, []byte("# known breaches\n32CA9FC1A0F5B6330E3F4C8C1BBECDE9BEDB9573:12345\n"), 0o600)
+	policyFile := filepath.Join(dir, "policy.json")
+	os.WriteFile(policyFile, []byte(`{"min_length": 10, "require": ["upper", "digit", "symbol"], "breached_file": "`+breachedFile+`"}`), 0o600)
+
+	policy, err := LoadPasswordPolicy(policyFile)
+	if err != nil {
+		t.Fatalf("LoadPasswordPolicy() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		password  string
+		wantRules []string
+	}{
+		{name: "Acceptable", password: "Correct-horse-42", wantRules: nil},
+		{name: "Empty", password: "", wantRules: []string{"min_length", ClassUpper, ClassDigit, ClassSymbol}},
+		{name: "Too short", password: "Ab1!", wantRules: []string{"min_length"}},
+		{name: "Too long", password: "A1!" + strings.Repeat("a", 70), wantRules: []string{"max_length"}},
+		{name: "Missing classes", password: "correcthorse", wantRules: []string{ClassUpper, ClassDigit, ClassSymbol}},
+		{name: "Breached", password: "Password1!", wantRules: []string{"breached"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotRules []string
+			for _, violation := range policy.Check(tt.password) {
+				gotRules = append(gotRules, violation.Rule)
+			}
+			if !reflect.DeepEqual(gotRules, tt.wantRules) {
+				t.Errorf("Check() rules = %v, want %v", gotRules, tt.wantRules)
+			}
+		})
+	}
+}
+
+func TestLoadPasswordPolicyRejectsLongMaxLength(t *testing.T) {
+	policyFile := filepath.Join(t.TempDir(), "policy.json")
+	os.WriteFile(policyFile, []byte(`{"max_length": 100}`), 0o600)
+
+	if _, err := LoadPasswordPolicy(policyFile); err == nil {
+		t.Errorf("LoadPasswordPolicy() accepted a max_length bcrypt would truncate")
+	}
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	UserID    uuid.UUID
+	Role      string
+	TokenID   string
+	ExpiresAt time.Time
+	// APIKeyID and Scopes are set for callers using a personal API key.
+	APIKeyID uuid.UUID
+	Scopes   []string
+	// ClientID is set, along with Scopes, for OAuth clients acting for the
+	// user.
+	ClientID uuid.UUID
+}
+
+func (p Principal) HasRole(role string) bool {
+	return p.Role == role
+}
+
+func (p Principal) IsAPIKey() bool {
+	return p.APIKeyID != uuid.Nil
+}
+
+func (p Principal) IsClient() bool {
+	return p.ClientID != uuid.Nil
+}
+
+// IsScoped reports whether the caller is limited to Scopes, rather than
+// being the user themselves.
+func (p Principal) IsScoped() bool {
+	return p.IsAPIKey() || p.IsClient()
+}
+
+// HasScope reports whether the caller may act within scope. Access tokens
+// issued to the user may do anything their user can.
+func (p Principal) HasScope(scope string) bool {
+	return !p.IsScoped() || slices.Contains(p.Scopes, scope)
+}
+
+// Principal returns the caller described by validated access token claims.
+func (c *Claims) Principal() Principal {
+	principal := Principal{
+		UserID:  uuid.MustParse(c.Subject),
+		Role:    c.Role,
+		TokenID: c.ID,
+	}
+	if c.ExpiresAt != nil {
+		principal.ExpiresAt = c.ExpiresAt.Time
+	}
+	if c.ClientID != "" {
+		principal.ClientID = uuid.MustParse(c.ClientID)
+		principal.Scopes = strings.Fields(c.Scope)
+	}
+	return principal
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext returns the principal stored by WithPrincipal. The
+// boolean is false when the request was not authenticated.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestPrincipalFromContext(t *testing.T) {
+	if _, ok := PrincipalFromContext(context.Background()); ok {
+		t.Errorf("PrincipalFromContext() found a principal in an empty context")
+	}
+
+	userID := uuid.New()
+	token, _ := MakeJWT(userID, RoleAdmin, NewHMACKeySet("secret"), time.Hour)
+	claims, err := ParseJWT(token, NewHMACKeySet("secret"))
+	if err != nil {
+		t.Fatalf("ParseJWT() error = %v", err)
+	}
+
+	ctx := WithPrincipal(context.Background(), claims.Principal())
+	got, ok := PrincipalFromContext(ctx)
+	if !ok {
+		t.Fatalf("PrincipalFromContext() found no principal")
+	}
+	if got.UserID != userID || !got.HasRole(RoleAdmin) {
+		t.Errorf("PrincipalFromContext() = %+v, want user %v with role %q", got, userID, RoleAdmin)
+	}
+	if time.Until(got.ExpiresAt) <= 0 || time.Until(got.ExpiresAt) > time.Hour {
+		t.Errorf("PrincipalFromContext() expires at %v, want within the next hour", got.ExpiresAt)
+	}
+}
@@ -0,0 +1,23 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+)
+
+// MakePasswordResetToken returns a random single-use token for resetting a
+// password. It is short enough to paste from an email.
+func MakePasswordResetToken() (string, error) {
+	tokenBytes := make([]byte, 32)
+	_, err := rand.Read(tokenBytes)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(tokenBytes), nil
+}
+
+// HashPasswordResetToken returns the digest stored for a reset token, so the
+// tokens in the database can't be used to take over accounts.
+func HashPasswordResetToken(token string) string {
+	return hashToken(token)
+}
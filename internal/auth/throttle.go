@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// ThrottlePolicy says how a Throttle slows down repeated failures.
+type ThrottlePolicy struct {
+	// FreeAttempts is how many failures are allowed before any backoff.
+	FreeAttempts int
+	// BaseDelay is the backoff after the first failure past FreeAttempts. It
+	// doubles with each further failure, up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// LockoutAfter failures lock the key out for Lockout. Failures are
+	// forgotten once Lockout has passed without new ones.
+	LockoutAfter int
+	Lockout      time.Duration
+}
+
+// Throttle counts failed attempts per key, such as an account or a client
+// address, and makes further attempts wait. Counts are kept in memory, so
+// every instance throttles on its own.
+type Throttle struct {
+	policy ThrottlePolicy
+	now    func() time.Time
+
+	mu        sync.Mutex
+	entries   map[string]throttleEntry
+	nextSweep time.Time
+}
+
+type throttleEntry struct {
+	failures     int
+	lastFailure  time.Time
+	blockedUntil time.Time
+}
+
+func NewThrottle(policy ThrottlePolicy) *Throttle {
+	return &Throttle{
+		policy:  policy,
+		now:     time.Now,
+		entries: make(map[string]throttleEntry),
+	}
+}
+
+// Attempt reserves an attempt of key, counting it as a failure up front so
+// that concurrent attempts can't all get in before any of them fails. When
+// key has to wait nothing is counted, and Attempt returns the wait and false.
+// Attempts that turn out to succeed are taken back with Reset or Undo.
+func (t *Throttle) Attempt(key string) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.sweep(now)
+
+	if wait := t.entries[key].blockedUntil.Sub(now); wait > 0 {
+		return wait, false
+	}
+	t.fail(key, now)
+	return 0, true
+}
+
+// Undo takes back an attempt of key that didn't fail, keeping the failures
+// before it.
+func (t *Throttle) Undo(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.entries[key]
+	if !ok {
+		return
+	}
+	entry.failures--
+	if entry.failures <= 0 {
+		delete(t.entries, key)
+		return
+	}
+	entry.blockedUntil = t.blockedUntil(entry.failures, entry.lastFailure)
+	t.entries[key] = entry
+}
+
+// fail counts a failure of key. t.mu must be held.
+func (t *Throttle) fail(key string, now time.Time) {
+	entry := t.entries[key]
+	if t.expired(entry, now) {
+		entry = throttleEntry{}
+	}
+	entry.failures++
+	entry.lastFailure = now
+	entry.blockedUntil = t.blockedUntil(entry.failures, now)
+	t.entries[key] = entry
+}
+
+// blockedUntil returns until when a key with failures, the last of them at
+// last, has to wait.
+func (t *Throttle) blockedUntil(failures int, last time.Time) time.Time {
+	switch {
+	case t.policy.LockoutAfter > 0 && failures >= t.policy.LockoutAfter:
+		return last.Add(t.policy.Lockout)
+	case failures > t.policy.FreeAttempts:
+		delay := t.policy.BaseDelay
+		for i := t.policy.FreeAttempts + 1; i < failures && delay < t.policy.MaxDelay; i++ {
+			delay *= 2
+		}
+		return last.Add(min(delay, t.policy.MaxDelay))
+	}
+	return time.Time{}
+}
+
+// Reset forgets the failures of key, after it finally succeeded.
+func (t *Throttle) Reset(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.entries, key)
+}
+
+func (t *Throttle) expired(entry throttleEntry, now time.Time) bool {
+	return !now.Before(entry.blockedUntil) && !now.Before(entry.lastFailure.Add(t.policy.Lockout))
+}
+
+// sweep drops forgotten entries, at most once per Lockout, so that keys made
+// up by an attacker don't pile up. t.mu must be held.
+func (t *Throttle) sweep(now time.Time) {
+	if now.Before(t.nextSweep) {
+		return
+	}
+	for key, entry := range t.entries {
+		if t.expired(entry, now) {
+			delete(t.entries, key)
+		}
+	}
+	t.nextSweep = now.Add(t.policy.Lockout)
+}
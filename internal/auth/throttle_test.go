@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThrottle(t *testing.T) {
+	clock := time.Now()
+	th := NewThrottle(ThrottlePolicy{
+		FreeAttempts: 2,
+		BaseDelay:    time.Second,
+		MaxDelay:     4 * time.Second,
+		LockoutAfter: 7,
+		Lockout:      time.Hour,
+	})
+	th.now = func() time.Time { return clock }
+	wait := func(key string) time.Duration {
+		return max(th.entries[key].blockedUntil.Sub(clock), 0)
+	}
+
+	// free attempts, then 1s, 2s, 4s and capped at 4s
+	wantWaits := []time.Duration{0, 0, time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second}
+	for i, want := range wantWaits {
+		if _, ok := th.Attempt("a@b.co"); !ok {
+			t.Fatalf("Attempt() %d = false, want true", i+1)
+		}
+		if got := wait("a@b.co"); got != want {
+			t.Errorf("wait after %d failures = %v, want %v", i+1, got, want)
+		}
+		clock = clock.Add(want)
+	}
+
+	if got, ok := th.Attempt("other@b.co"); !ok {
+		t.Errorf("Attempt() of another key = %v, false, want true", got)
+	}
+
+	th.Attempt("a@b.co")
+	if got, ok := th.Attempt("a@b.co"); ok || got != time.Hour {
+		t.Errorf("Attempt() after lockout = %v, %v, want %v, false", got, ok, time.Hour)
+	}
+
+	// failures are forgotten once the lockout passed
+	clock = clock.Add(time.Hour)
+	if _, ok := th.Attempt("a@b.co"); !ok {
+		t.Fatal("Attempt() after the lockout passed = false, want true")
+	}
+	if got := wait("a@b.co"); got != 0 {
+		t.Errorf("wait after a failure past the lockout = %v, want 0", got)
+	}
+
+	th.Attempt("a@b.co")
+	th.Attempt("a@b.co")
+	th.Reset("a@b.co")
+	if got := wait("a@b.co"); got != 0 {
+		t.Errorf("wait after Reset() = %v, want 0", got)
+	}
+}
+
+func TestThrottleAttempt(t *testing.T) {
+	clock := time.Now()
+	th := NewThrottle(ThrottlePolicy{
+		FreeAttempts: 2,
+		BaseDelay:    time.Second,
+		MaxDelay:     4 * time.Second,
+		LockoutAfter: 7,
+		Lockout:      time.Hour,
+	})
+	th.now = func() time.Time { return clock }
+
+	// attempts count before they finish, so parallel ones can't get past
+	// the free attempts
+	for i := range 3 {
+		if _, ok := th.Attempt("a@b.co"); !ok {
+			t.Fatalf("Attempt() %d = false, want true", i+1)
+		}
+	}
+	if wait, ok := th.Attempt("a@b.co"); ok || wait != time.Second {
+		t.Errorf("Attempt() past the free attempts = %v, %v, want 1s, false", wait, ok)
+	}
+
+	// a successful attempt doesn't count, the failures before it still do
+	th.Undo("a@b.co")
+	if _, ok := th.Attempt("a@b.co"); !ok {
+		t.Fatal("Attempt() after Undo() = false, want true")
+	}
+	th.Undo("a@b.co")
+	th.Undo("a@b.co")
+	for i := range 2 {
+		if _, ok := th.Attempt("a@b.co"); !ok {
+			t.Fatalf("Attempt() %d after undoing to one failure = false, want true", i+1)
+		}
+	}
+	if wait, ok := th.Attempt("a@b.co"); ok || wait != time.Second {
+		t.Errorf("Attempt() after three failures = %v, %v, want 1s, false", wait, ok)
+	}
+
+	th.Reset("a@b.co")
+	if _, ok := th.Attempt("a@b.co"); !ok {
+		t.Error("Attempt() after Reset() = false, want true")
+	}
+
+	// a zero policy never throttles
+	free := NewThrottle(ThrottlePolicy{})
+	for i := range 100 {
+		if wait, ok := free.Attempt("127.0.0.1"); !ok {
+			t.Fatalf("Attempt() %d with a zero policy = %v, false, want true", i+1, wait)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package auth
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 key of the RFC 6238 test vectors,
+// "12345678901234567890" in base32.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateTOTPCode(t *testing.T) {
+	// the last six digits of the RFC 6238 SHA-1 test vectors
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, v := range vectors {
+		step, err := ValidateTOTPCode(rfc6238Secret, v.code, time.Unix(v.unix, 0), 0)
+		if err != nil {
+			t.Errorf("ValidateTOTPCode(%q) at %d error = %v", v.code, v.unix, err)
+		}
+		if want := v.unix / 30; step != want {
+			t.Errorf("ValidateTOTPCode(%q) at %d step = %d, want %d", v.code, v.unix, step, want)
+		}
+	}
+
+	now := time.Unix(1111111111, 0)
+	tests := []struct {
+		name         string
+		code         string
+		now          time.Time
+		lastUsedStep int64
+		wantErr      bool
+	}{
+		{name: "previous step", code: "050471", now: now.Add(30 * time.Second)},
+		{name: "next step", code: "050471", now: now.Add(-30 * time.Second)},
+		{name: "too old", code: "050471", now: now.Add(90 * time.Second), wantErr: true},
+		{name: "spaces are ignored", code: "050 471", now: now},
+		{name: "wrong code", code: "123456", now: now, wantErr: true},
+		{name: "too short", code: "05047", now: now, wantErr: true},
+		{name: "step already used", code: "050471", now: now, lastUsedStep: now.Unix() / 30, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidateTOTPCode(rfc6238Secret, tt.code, tt.now, tt.lastUsedStep)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTOTPCode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidTOTPCode) {
+				t.Errorf("ValidateTOTPCode() error = %v, want ErrInvalidTOTPCode", err)
+			}
+		})
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret() error = %v", err)
+	}
+
+	u, err := url.Parse(TOTPURI("Chirpy", "a@example.com", secret))
+	if err != nil {
+		t.Fatalf("TOTPURI() is not a URL: %v", err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/Chirpy:a@example.com" {
+		t.Errorf("TOTPURI() = %q, want otpauth://totp/Chirpy:a@example.com", u)
+	}
+	if got := u.Query().Get("secret"); got != secret {
+		t.Errorf("TOTPURI() secret = %q, want %q", got, secret)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := MakeRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("MakeRecoveryCodes() error = %v", err)
+	}
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("MakeRecoveryCodes() code %q, want the form abcde-fghij", code)
+		}
+		if seen[code] {
+			t.Errorf("MakeRecoveryCodes() returned %q twice", code)
+		}
+		seen[code] = true
+	}
+
+	if HashRecoveryCode("abcde-fghij") != HashRecoveryCode("ABCDE FGHIJ") {
+		t.Errorf("HashRecoveryCode() depends on case or separators")
+	}
+}
@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestValidateEmailVerificationToken(t *testing.T) {
+	keys := NewHMACKeySet("secret")
+	userID := uuid.New()
+
+	token, err := MakeEmailVerificationToken(userID, "a@example.com", keys, time.Hour)
+	if err != nil {
+		t.Fatalf("MakeEmailVerificationToken() error = %v", err)
+	}
+	gotUserID, gotEmail, err := ValidateEmailVerificationToken(token, keys)
+	if err != nil {
+		t.Fatalf("ValidateEmailVerificationToken() error = %v", err)
+	}
+	if gotUserID != userID || gotEmail != "a@example.com" {
+		t.Errorf("ValidateEmailVerificationToken() = %v, %q, want %v, %q", gotUserID, gotEmail, userID, "a@example.com")
+	}
+
+	if _, err := ParseJWT(token, keys); err == nil {
+		t.Errorf("ParseJWT() accepted a verification token as an access token")
+	}
+
+	accessToken, _ := MakeJWT(userID, RoleUser, keys, time.Hour)
+	if _, _, err := ValidateEmailVerificationToken(accessToken, keys); err == nil {
+		t.Errorf("ValidateEmailVerificationToken() accepted an access token")
+	}
+
+	expired, _ := MakeEmailVerificationToken(userID, "a@example.com", keys, -time.Minute)
+	if _, _, err := ValidateEmailVerificationToken(expired, keys); err == nil {
+		t.Errorf("ValidateEmailVerificationToken() accepted an expired token")
+	}
+}
@@ -7,23 +7,26 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 const createChirp = `-- name: CreateChirp :one
 
-INSERT INTO chirps (id, created_at, updated_at, user_id, body)
-VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2) RETURNING id, created_at, updated_at, body, user_id
+INSERT INTO chirps (id, created_at, updated_at, user_id, body, reply_to_id)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2, $3) RETURNING id, created_at, updated_at, body, user_id, body_tsv, like_count, reply_to_id, deleted_at, reply_count
 `
 
 type CreateChirpParams struct {
-	UserID uuid.UUID `json:"user_id"`
-	Body   string    `json:"body"`
+	UserID    uuid.UUID     `json:"user_id"`
+	Body      string        `json:"body"`
+	ReplyToID uuid.NullUUID `json:"reply_to_id"`
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.UserID, arg.Body)
+	row := q.db.QueryRowContext(ctx, createChirp, arg.UserID, arg.Body, arg.ReplyToID)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -31,6 +34,11 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.BodyTsv,
+		&i.LikeCount,
+		&i.ReplyToID,
+		&i.DeletedAt,
+		&i.ReplyCount,
 	)
 	return i, err
 }
@@ -39,6 +47,7 @@ const deleteChirpById = `-- name: DeleteChirpById :one
 DELETE
 FROM chirps
 WHERE id = $1 AND user_id = $2
+    AND NOT EXISTS (SELECT 1 FROM chirps replies WHERE replies.reply_to_id = chirps.id)
 RETURNING id
 `
 
@@ -47,6 +56,7 @@ type DeleteChirpByIdParams struct {
 	UserID uuid.UUID `json:"user_id"`
 }
 
+// Chirps with replies are kept, see MarkChirpDeleted.
 func (q *Queries) DeleteChirpById(ctx context.Context, arg DeleteChirpByIdParams) (uuid.UUID, error) {
 	row := q.db.QueryRowContext(ctx, deleteChirpById, arg.ID, arg.UserID)
 	var id uuid.UUID
@@ -56,15 +66,41 @@ func (q *Queries) DeleteChirpById(ctx context.Context, arg DeleteChirpByIdParams
 
 const getAllChirps = `-- name: GetAllChirps :many
 
-SELECT id, created_at, updated_at, body, user_id
+SELECT id, created_at, updated_at, body, user_id, body_tsv, like_count, reply_to_id, deleted_at, reply_count
 FROM chirps
+WHERE deleted_at IS NULL AND user_id IN (SELECT id FROM users WHERE deleted_at IS NULL) AND (
+    $1::timestamp IS NULL
+    OR ($2::text = 'ASC' AND (created_at, id) > ($1::timestamp, $3::uuid))
+    OR ($2::text = 'DESC' AND (created_at, id) < ($1::timestamp, $3::uuid))
+    OR ($2::text = 'LIKES' AND (like_count, created_at, id) < ($4::integer, $1::timestamp, $3::uuid))
+)
 ORDER BY
-    CASE WHEN $1::text = 'ASC' THEN created_at END ASC,
-    CASE WHEN $1::text = 'DESC' THEN created_at END DESC
+    CASE WHEN $2::text = 'ASC' THEN created_at END ASC,
+    CASE WHEN $2::text = 'ASC' THEN id END ASC,
+    CASE WHEN $2::text = 'DESC' THEN created_at END DESC,
+    CASE WHEN $2::text = 'DESC' THEN id END DESC,
+    CASE WHEN $2::text = 'LIKES' THEN like_count END DESC,
+    CASE WHEN $2::text = 'LIKES' THEN created_at END DESC,
+    CASE WHEN $2::text = 'LIKES' THEN id END DESC
+LIMIT $5
 `
 
-func (q *Queries) GetAllChirps(ctx context.Context, sort string) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getAllChirps, sort)
+type GetAllChirpsParams struct {
+	CursorCreatedAt sql.NullTime  `json:"cursor_created_at"`
+	Sort            string        `json:"sort"`
+	CursorID        uuid.NullUUID `json:"cursor_id"`
+	CursorLikeCount sql.NullInt32 `json:"cursor_like_count"`
+	RowLimit        int32         `json:"row_limit"`
+}
+
+func (q *Queries) GetAllChirps(ctx context.Context, arg GetAllChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getAllChirps,
+		arg.CursorCreatedAt,
+		arg.Sort,
+		arg.CursorID,
+		arg.CursorLikeCount,
+		arg.RowLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -78,6 +114,11 @@ func (q *Queries) GetAllChirps(ctx context.Context, sort string) ([]Chirp, error
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.BodyTsv,
+			&i.LikeCount,
+			&i.ReplyToID,
+			&i.DeletedAt,
+			&i.ReplyCount,
 		); err != nil {
 			return nil, err
 		}
@@ -94,21 +135,97 @@ func (q *Queries) GetAllChirps(ctx context.Context, sort string) ([]Chirp, error
 
 const getAllChirpsByAuthor = `-- name: GetAllChirpsByAuthor :many
 
-SELECT id, created_at, updated_at, body, user_id
+SELECT id, created_at, updated_at, body, user_id, body_tsv, like_count, reply_to_id, deleted_at, reply_count
 FROM chirps
-WHERE user_id = $1
+WHERE user_id = $1 AND deleted_at IS NULL AND user_id IN (SELECT id FROM users WHERE deleted_at IS NULL) AND (
+    $2::timestamp IS NULL
+    OR ($3::text = 'ASC' AND (created_at, id) > ($2::timestamp, $4::uuid))
+    OR ($3::text = 'DESC' AND (created_at, id) < ($2::timestamp, $4::uuid))
+    OR ($3::text = 'LIKES' AND (like_count, created_at, id) < ($5::integer, $2::timestamp, $4::uuid))
+)
 ORDER BY
-    CASE WHEN $2::text = 'ASC' THEN created_at END ASC,
-    CASE WHEN $2::text = 'DESC' THEN created_at END DESC
+    CASE WHEN $3::text = 'ASC' THEN created_at END ASC,
+    CASE WHEN $3::text = 'ASC' THEN id END ASC,
+    CASE WHEN $3::text = 'DESC' THEN created_at END DESC,
+    CASE WHEN $3::text = 'DESC' THEN id END DESC,
+    CASE WHEN $3::text = 'LIKES' THEN like_count END DESC,
+    CASE WHEN $3::text = 'LIKES' THEN created_at END DESC,
+    CASE WHEN $3::text = 'LIKES' THEN id END DESC
+LIMIT $6
 `
 
 type GetAllChirpsByAuthorParams struct {
-	UserID uuid.UUID `json:"user_id"`
-	Sort   string    `json:"sort"`
+	UserID          uuid.UUID     `json:"user_id"`
+	CursorCreatedAt sql.NullTime  `json:"cursor_created_at"`
+	Sort            string        `json:"sort"`
+	CursorID        uuid.NullUUID `json:"cursor_id"`
+	CursorLikeCount sql.NullInt32 `json:"cursor_like_count"`
+	RowLimit        int32         `json:"row_limit"`
 }
 
 func (q *Queries) GetAllChirpsByAuthor(ctx context.Context, arg GetAllChirpsByAuthorParams) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getAllChirpsByAuthor, arg.UserID, arg.Sort)
+	rows, err := q.db.QueryContext(ctx, getAllChirpsByAuthor,
+		arg.UserID,
+		arg.CursorCreatedAt,
+		arg.Sort,
+		arg.CursorID,
+		arg.CursorLikeCount,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.BodyTsv,
+			&i.LikeCount,
+			&i.ReplyToID,
+			&i.DeletedAt,
+			&i.ReplyCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpAncestors = `-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors (id, reply_to_id, depth) AS (
+    SELECT parent.id, parent.reply_to_id, 1
+    FROM chirps parent
+    JOIN chirps child ON child.reply_to_id = parent.id
+    WHERE child.id = $1
+    UNION ALL
+    SELECT parent.id, parent.reply_to_id, ancestors.depth + 1
+    FROM chirps parent
+    JOIN ancestors ON ancestors.reply_to_id = parent.id
+)
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.body_tsv, chirps.like_count, chirps.reply_to_id, chirps.deleted_at, chirps.reply_count
+FROM chirps
+JOIN ancestors ON ancestors.id = chirps.id
+WHERE chirps.user_id IN (SELECT id FROM users WHERE deleted_at IS NULL)
+ORDER BY ancestors.depth DESC
+`
+
+// Returns the chirps a chirp replies to, root first. Deleted chirps are
+// included so the chain stays whole, but those of deleted accounts aren't.
+func (q *Queries) GetChirpAncestors(ctx context.Context, id uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpAncestors, id)
 	if err != nil {
 		return nil, err
 	}
@@ -122,6 +239,11 @@ func (q *Queries) GetAllChirpsByAuthor(ctx context.Context, arg GetAllChirpsByAu
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.BodyTsv,
+			&i.LikeCount,
+			&i.ReplyToID,
+			&i.DeletedAt,
+			&i.ReplyCount,
 		); err != nil {
 			return nil, err
 		}
@@ -138,9 +260,9 @@ func (q *Queries) GetAllChirpsByAuthor(ctx context.Context, arg GetAllChirpsByAu
 
 const getChirpById = `-- name: GetChirpById :one
 
-SELECT id, created_at, updated_at, body, user_id
+SELECT id, created_at, updated_at, body, user_id, body_tsv, like_count, reply_to_id, deleted_at, reply_count
 FROM chirps
-WHERE id = $1
+WHERE id = $1 AND deleted_at IS NULL AND user_id IN (SELECT id FROM users WHERE deleted_at IS NULL)
 `
 
 func (q *Queries) GetChirpById(ctx context.Context, id uuid.UUID) (Chirp, error) {
@@ -152,6 +274,164 @@ func (q *Queries) GetChirpById(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.BodyTsv,
+		&i.LikeCount,
+		&i.ReplyToID,
+		&i.DeletedAt,
+		&i.ReplyCount,
 	)
 	return i, err
 }
+
+const getChirpDescendants = `-- name: GetChirpDescendants :many
+WITH RECURSIVE descendants (id) AS (
+    SELECT replies.id
+    FROM chirps replies
+    WHERE replies.reply_to_id = $1
+    UNION ALL
+    SELECT replies.id
+    FROM chirps replies
+    JOIN descendants ON replies.reply_to_id = descendants.id
+)
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.body_tsv, chirps.like_count, chirps.reply_to_id, chirps.deleted_at, chirps.reply_count
+FROM chirps
+JOIN descendants ON descendants.id = chirps.id
+WHERE chirps.user_id IN (SELECT id FROM users WHERE deleted_at IS NULL) AND (
+    $2::timestamp IS NULL
+    OR (chirps.created_at, chirps.id) > ($2::timestamp, $3::uuid)
+)
+ORDER BY chirps.created_at, chirps.id
+LIMIT $4
+`
+
+type GetChirpDescendantsParams struct {
+	ID              uuid.UUID     `json:"id"`
+	CursorCreatedAt sql.NullTime  `json:"cursor_created_at"`
+	CursorID        uuid.NullUUID `json:"cursor_id"`
+	RowLimit        int32         `json:"row_limit"`
+}
+
+// Returns the replies to a chirp and their replies in turn, oldest first.
+// Deleted chirps are included like in GetChirpAncestors.
+func (q *Queries) GetChirpDescendants(ctx context.Context, arg GetChirpDescendantsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpDescendants,
+		arg.ID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.BodyTsv,
+			&i.LikeCount,
+			&i.ReplyToID,
+			&i.DeletedAt,
+			&i.ReplyCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markChirpDeleted = `-- name: MarkChirpDeleted :one
+UPDATE chirps SET body = '', deleted_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+RETURNING id
+`
+
+type MarkChirpDeletedParams struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func (q *Queries) MarkChirpDeleted(ctx context.Context, arg MarkChirpDeletedParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, markChirpDeleted, arg.ID, arg.UserID)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const searchChirps = `-- name: SearchChirps :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.body_tsv, chirps.like_count, chirps.reply_to_id, chirps.deleted_at, chirps.reply_count, ts_rank(chirps.body_tsv, query)::real AS rank
+FROM chirps, to_tsquery('english', $1::text) query
+WHERE chirps.body_tsv @@ query
+    AND chirps.deleted_at IS NULL
+    AND ($2::uuid IS NULL OR chirps.user_id = $2::uuid)
+    AND chirps.user_id IN (SELECT id FROM users WHERE deleted_at IS NULL)
+ORDER BY rank DESC, chirps.created_at DESC, chirps.id DESC
+LIMIT $3
+`
+
+type SearchChirpsParams struct {
+	Query    string        `json:"query"`
+	AuthorID uuid.NullUUID `json:"author_id"`
+	RowLimit int32         `json:"row_limit"`
+}
+
+type SearchChirpsRow struct {
+	ID         uuid.UUID     `json:"id"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+	Body       string        `json:"body"`
+	UserID     uuid.UUID     `json:"user_id"`
+	BodyTsv    string        `json:"-"`
+	LikeCount  int32         `json:"like_count"`
+	ReplyToID  uuid.NullUUID `json:"reply_to_id"`
+	DeletedAt  sql.NullTime  `json:"-"`
+	ReplyCount int32         `json:"reply_count"`
+	Rank       float32       `json:"rank"`
+}
+
+func (q *Queries) SearchChirps(ctx context.Context, arg SearchChirpsParams) ([]SearchChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirps, arg.Query, arg.AuthorID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchChirpsRow
+	for rows.Next() {
+		var i SearchChirpsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.BodyTsv,
+			&i.LikeCount,
+			&i.ReplyToID,
+			&i.DeletedAt,
+			&i.ReplyCount,
+			&i.Rank,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,901 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// refreshTokenLifetime mirrors the interval used by CreateRefreshToken.
+const refreshTokenLifetime = 60 * 24 * time.Hour
+
+// passwordResetTokenLifetime mirrors the interval used by
+// CreatePasswordResetToken.
+const passwordResetTokenLifetime = 30 * time.Minute
+
+// MemoryStore is an in-memory Store. It keeps the same constraints as the
+// Postgres schema in sql/schema: unique emails and chirp bodies, foreign keys
+// with ON DELETE CASCADE, and refresh token expiry and revocation. Constraint
+// failures are reported as *pq.Error values carrying the same SQLSTATE codes
+// Postgres would use, and missing rows as sql.ErrNoRows.
+type MemoryStore struct {
+	mu  sync.Mutex
+	now func() time.Time
+	memoryTables
+}
+
+// memoryTables holds the rows of a MemoryStore.
+type memoryTables struct {
+	users         map[uuid.UUID]User
+	chirps        map[uuid.UUID]Chirp
+	refreshTokens map[string]RefreshToken // keyed by token hash
+	decisions     map[uuid.UUID]ChirpModerationDecision
+	bannedWords   map[string]BannedWord
+	revokedTokens map[uuid.UUID]RevokedAccessToken
+	resetTokens   map[string]PasswordResetToken // keyed by token hash
+	totps         map[uuid.UUID]UserTotp        // keyed by user ID
+	recoveryCodes map[string]TotpRecoveryCode   // keyed by code hash
+	apiKeys       map[uuid.UUID]ApiKey
+	oauthClients  map[uuid.UUID]OauthClient
+	oauthCodes    map[string]OauthAuthorizationCode // keyed by code hash
+	identities    map[userIdentityKey]UserIdentity
+	chirpLikes    map[chirpLikeKey]ChirpLike
+	// bannedWordChanges is kept in insertion order
+	bannedWordChanges []BannedWordChange
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		now: time.Now,
+		memoryTables: memoryTables{
+			users:         make(map[uuid.UUID]User),
+			chirps:        make(map[uuid.UUID]Chirp),
+			refreshTokens: make(map[string]RefreshToken),
+			decisions:     make(map[uuid.UUID]ChirpModerationDecision),
+			bannedWords:   make(map[string]BannedWord),
+			revokedTokens: make(map[uuid.UUID]RevokedAccessToken),
+			resetTokens:   make(map[string]PasswordResetToken),
+			totps:         make(map[uuid.UUID]UserTotp),
+			recoveryCodes: make(map[string]TotpRecoveryCode),
+			apiKeys:       make(map[uuid.UUID]ApiKey),
+			oauthClients:  make(map[uuid.UUID]OauthClient),
+			oauthCodes:    make(map[string]OauthAuthorizationCode),
+			identities:    make(map[userIdentityKey]UserIdentity),
+			chirpLikes:    make(map[chirpLikeKey]ChirpLike),
+		},
+	}
+}
+
+// clone copies the tables, so that writes to the copy leave t as it was.
+func (t memoryTables) clone() memoryTables {
+	return memoryTables{
+		users:             maps.Clone(t.users),
+		chirps:            maps.Clone(t.chirps),
+		refreshTokens:     maps.Clone(t.refreshTokens),
+		decisions:         maps.Clone(t.decisions),
+		bannedWords:       maps.Clone(t.bannedWords),
+		revokedTokens:     maps.Clone(t.revokedTokens),
+		resetTokens:       maps.Clone(t.resetTokens),
+		totps:             maps.Clone(t.totps),
+		recoveryCodes:     maps.Clone(t.recoveryCodes),
+		apiKeys:           maps.Clone(t.apiKeys),
+		oauthClients:      maps.Clone(t.oauthClients),
+		oauthCodes:        maps.Clone(t.oauthCodes),
+		identities:        maps.Clone(t.identities),
+		chirpLikes:        maps.Clone(t.chirpLikes),
+		bannedWordChanges: slices.Clone(t.bannedWordChanges),
+	}
+}
+
+// InTx runs fn on a MemoryStore sharing s's tables. s stays locked until fn
+// returns, so other callers wait for the transaction to finish, and if fn
+// fails the tables are put back the way they were.
+func (s *MemoryStore) InTx(ctx context.Context, fn func(Querier) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved := s.memoryTables.clone()
+	tx := &MemoryStore{now: s.now, memoryTables: s.memoryTables}
+	if err := fn(tx); err != nil {
+		s.memoryTables = saved
+		return err
+	}
+	// appends to bannedWordChanges only changed tx's slice
+	s.memoryTables = tx.memoryTables
+	return nil
+}
+
+// timestamp returns the current time with the precision of a Postgres
+// TIMESTAMP column.
+func (s *MemoryStore) timestamp() time.Time {
+	return s.now().UTC().Truncate(time.Microsecond)
+}
+
+func uniqueViolation(table, constraint string) error {
+	return &pq.Error{
+		Code:       "23505",
+		Message:    "duplicate key value violates unique constraint \"" + constraint + "\"",
+		Table:      table,
+		Constraint: constraint,
+	}
+}
+
+func foreignKeyViolation(table, constraint string) error {
+	return &pq.Error{
+		Code:       "23503",
+		Message:    "insert or update on table \"" + table + "\" violates foreign key constraint \"" + constraint + "\"",
+		Table:      table,
+		Constraint: constraint,
+	}
+}
+
+func sortChirps(chirps []Chirp, order string) {
+	sort.Slice(chirps, func(i, j int) bool {
+		return chirpPrecedes(chirps[i], chirps[j], order)
+	})
+}
+
+// chirpPrecedes reports whether a is listed before b in the given order.
+// LIKES lists the most liked chirps first, and the newest among equally
+// liked ones.
+func chirpPrecedes(a, b Chirp, order string) bool {
+	switch order {
+	case "DESC":
+		return chirpBefore(b, a)
+	case "LIKES":
+		if a.LikeCount != b.LikeCount {
+			return a.LikeCount > b.LikeCount
+		}
+		return chirpBefore(b, a)
+	}
+	return chirpBefore(a, b)
+}
+
+// chirpBefore orders chirps by (created_at, id), the key used for cursors.
+func chirpBefore(a, b Chirp) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return a.ID.String() < b.ID.String()
+}
+
+// pageChirps sorts chirps and returns at most limit of them that come after
+// the cursor in the requested order, like the keyset queries in chirps.sql.
+func pageChirps(chirps []Chirp, order string, cursorCreatedAt sql.NullTime, cursorID uuid.NullUUID, cursorLikeCount sql.NullInt32, limit int32) []Chirp {
+	sortChirps(chirps, order)
+
+	var items []Chirp
+	cursor := Chirp{CreatedAt: cursorCreatedAt.Time, ID: cursorID.UUID, LikeCount: cursorLikeCount.Int32}
+	for _, chirp := range chirps {
+		if int32(len(items)) >= limit {
+			break
+		}
+		if cursorCreatedAt.Valid && !chirpPrecedes(cursor, chirp, order) {
+			continue
+		}
+		items = append(items, chirp)
+	}
+	return items
+}
+
+// matchTsQuery evaluates the subset of to_tsquery syntax used by the search
+// handler: clauses joined by "&", where each clause is a lexeme or a
+// parenthesised "<->" phrase, and any lexeme may end in ":*" for a prefix
+// match. Unlike Postgres it does no stemming. The rank is the number of
+// clause matches found in body.
+func matchTsQuery(query, body string) (float32, bool) {
+	words := strings.FieldsFunc(strings.ToLower(body), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var rank float32
+	for _, clause := range strings.Split(query, " & ") {
+		clause = strings.TrimSuffix(strings.TrimPrefix(clause, "("), ")")
+		phrase := strings.Split(clause, " <-> ")
+
+		matches := 0
+		for i := 0; i+len(phrase) <= len(words); i++ {
+			matched := true
+			for k, lexeme := range phrase {
+				prefix, isPrefix := strings.CutSuffix(lexeme, ":*")
+				if isPrefix && !strings.HasPrefix(words[i+k], prefix) || !isPrefix && words[i+k] != lexeme {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				matches++
+			}
+		}
+		if matches == 0 {
+			return 0, false
+		}
+		rank += float32(matches)
+	}
+	return rank, true
+}
+
+func (s *MemoryStore) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[arg.UserID]; !ok {
+		return Chirp{}, foreignKeyViolation("chirps", "chirps_user_id_fkey")
+	}
+	if _, ok := s.chirps[arg.ReplyToID.UUID]; arg.ReplyToID.Valid && !ok {
+		return Chirp{}, foreignKeyViolation("chirps", "chirps_reply_to_id_fkey")
+	}
+	for _, chirp := range s.chirps {
+		if chirp.Body == arg.Body && !chirp.DeletedAt.Valid {
+			return Chirp{}, uniqueViolation("chirps", "chirps_body_key")
+		}
+	}
+
+	now := s.timestamp()
+	chirp := Chirp{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Body:      arg.Body,
+		UserID:    arg.UserID,
+		ReplyToID: arg.ReplyToID,
+	}
+	s.chirps[chirp.ID] = chirp
+	s.countReply(chirp, 1)
+	return chirp, nil
+}
+
+func (s *MemoryStore) DeleteChirpById(ctx context.Context, arg DeleteChirpByIdParams) (uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chirp, ok := s.chirps[arg.ID]
+	if !ok || chirp.UserID != arg.UserID {
+		return uuid.Nil, sql.ErrNoRows
+	}
+	for _, reply := range s.chirps {
+		if reply.ReplyToID.Valid && reply.ReplyToID.UUID == arg.ID {
+			return uuid.Nil, sql.ErrNoRows
+		}
+	}
+	s.deleteChirp(arg.ID)
+	return chirp.ID, nil
+}
+
+func (s *MemoryStore) GetAllChirps(ctx context.Context, arg GetAllChirpsParams) ([]Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []Chirp
+	for _, chirp := range s.chirps {
+		if !chirp.DeletedAt.Valid && s.chirpVisible(chirp) {
+			items = append(items, chirp)
+		}
+	}
+	return pageChirps(items, arg.Sort, arg.CursorCreatedAt, arg.CursorID, arg.CursorLikeCount, arg.RowLimit), nil
+}
+
+func (s *MemoryStore) GetAllChirpsByAuthor(ctx context.Context, arg GetAllChirpsByAuthorParams) ([]Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []Chirp
+	for _, chirp := range s.chirps {
+		if chirp.UserID == arg.UserID && !chirp.DeletedAt.Valid && s.chirpVisible(chirp) {
+			items = append(items, chirp)
+		}
+	}
+	return pageChirps(items, arg.Sort, arg.CursorCreatedAt, arg.CursorID, arg.CursorLikeCount, arg.RowLimit), nil
+}
+
+func (s *MemoryStore) GetChirpById(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chirp, ok := s.chirps[id]
+	if !ok || chirp.DeletedAt.Valid || !s.chirpVisible(chirp) {
+		return Chirp{}, sql.ErrNoRows
+	}
+	return chirp, nil
+}
+
+func (s *MemoryStore) GetChirpAncestors(ctx context.Context, id uuid.UUID) ([]Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []Chirp
+	for parentID := s.chirps[id].ReplyToID; parentID.Valid; {
+		parent, ok := s.chirps[parentID.UUID]
+		if !ok {
+			break
+		}
+		if s.chirpVisible(parent) {
+			items = append(items, parent)
+		}
+		parentID = parent.ReplyToID
+	}
+	slices.Reverse(items)
+	return items, nil
+}
+
+func (s *MemoryStore) GetChirpDescendants(ctx context.Context, arg GetChirpDescendantsParams) ([]Chirp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []Chirp
+	parents := []uuid.UUID{arg.ID}
+	for len(parents) > 0 {
+		parentID := parents[0]
+		parents = parents[1:]
+		for _, reply := range s.chirps {
+			if !reply.ReplyToID.Valid || reply.ReplyToID.UUID != parentID {
+				continue
+			}
+			parents = append(parents, reply.ID)
+			if s.chirpVisible(reply) {
+				items = append(items, reply)
+			}
+		}
+	}
+	return pageChirps(items, "ASC", arg.CursorCreatedAt, arg.CursorID, sql.NullInt32{}, arg.RowLimit), nil
+}
+
+func (s *MemoryStore) MarkChirpDeleted(ctx context.Context, arg MarkChirpDeletedParams) (uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chirp, ok := s.chirps[arg.ID]
+	if !ok || chirp.UserID != arg.UserID || chirp.DeletedAt.Valid {
+		return uuid.Nil, sql.ErrNoRows
+	}
+	s.countReply(chirp, -1)
+	now := s.timestamp()
+	chirp.Body = ""
+	chirp.DeletedAt = sql.NullTime{Time: now, Valid: true}
+	chirp.UpdatedAt = now
+	s.chirps[chirp.ID] = chirp
+	return chirp.ID, nil
+}
+
+func (s *MemoryStore) SearchChirps(ctx context.Context, arg SearchChirpsParams) ([]SearchChirpsRow, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []SearchChirpsRow
+	for _, chirp := range s.chirps {
+		if arg.AuthorID.Valid && chirp.UserID != arg.AuthorID.UUID {
+			continue
+		}
+		if chirp.DeletedAt.Valid || !s.chirpVisible(chirp) {
+			continue
+		}
+		rank, ok := matchTsQuery(arg.Query, chirp.Body)
+		if !ok {
+			continue
+		}
+		items = append(items, SearchChirpsRow{
+			ID:         chirp.ID,
+			CreatedAt:  chirp.CreatedAt,
+			UpdatedAt:  chirp.UpdatedAt,
+			Body:       chirp.Body,
+			UserID:     chirp.UserID,
+			LikeCount:  chirp.LikeCount,
+			ReplyToID:  chirp.ReplyToID,
+			ReplyCount: chirp.ReplyCount,
+			Rank:       rank,
+		})
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Rank != items[j].Rank {
+			return items[i].Rank > items[j].Rank
+		}
+		return chirpBefore(Chirp{CreatedAt: items[j].CreatedAt, ID: items[j].ID}, Chirp{CreatedAt: items[i].CreatedAt, ID: items[i].ID})
+	})
+	if int32(len(items)) > arg.RowLimit {
+		items = items[:arg.RowLimit]
+	}
+	return items, nil
+}
+
+func (s *MemoryStore) CreateChirpModerationDecision(ctx context.Context, arg CreateChirpModerationDecisionParams) (ChirpModerationDecision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.chirps[arg.ChirpID]; !ok {
+		return ChirpModerationDecision{}, foreignKeyViolation("chirp_moderation_decisions", "chirp_moderation_decisions_chirp_id_fkey")
+	}
+
+	decision := ChirpModerationDecision{
+		ID:          uuid.New(),
+		CreatedAt:   s.timestamp(),
+		ChirpID:     arg.ChirpID,
+		Rule:        arg.Rule,
+		Action:      arg.Action,
+		MatchedText: arg.MatchedText,
+	}
+	s.decisions[decision.ID] = decision
+	return decision, nil
+}
+
+func (s *MemoryStore) GetChirpModerationDecisions(ctx context.Context, chirpID uuid.UUID) ([]ChirpModerationDecision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []ChirpModerationDecision
+	for _, decision := range s.decisions {
+		if decision.ChirpID == chirpID {
+			items = append(items, decision)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].CreatedAt.Before(items[j].CreatedAt)
+	})
+	return items, nil
+}
+
+func (s *MemoryStore) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[arg.UserID]; !ok {
+		return RefreshToken{}, foreignKeyViolation("refresh_tokens", "refresh_tokens_user_id_fkey")
+	}
+	if _, ok := s.oauthClients[arg.ClientID.UUID]; arg.ClientID.Valid && !ok {
+		return RefreshToken{}, foreignKeyViolation("refresh_tokens", "refresh_tokens_client_id_fkey")
+	}
+	if _, ok := s.refreshTokens[arg.TokenHash]; ok {
+		return RefreshToken{}, uniqueViolation("refresh_tokens", "refresh_tokens_pkey")
+	}
+
+	now := s.timestamp()
+	refreshToken := RefreshToken{
+		TokenHash:  arg.TokenHash,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		UserID:     arg.UserID,
+		ExpiresAt:  now.Add(refreshTokenLifetime),
+		FamilyID:   arg.FamilyID,
+		ID:         uuid.New(),
+		LastUsedAt: arg.LastUsedAt,
+		UserAgent:  arg.UserAgent,
+		IpAddress:  arg.IpAddress,
+
+		AccessTokenID:        arg.AccessTokenID,
+		AccessTokenExpiresAt: arg.AccessTokenExpiresAt,
+		ClientID:             arg.ClientID,
+		Scopes:               slices.Clone(arg.Scopes),
+	}
+	s.refreshTokens[refreshToken.TokenHash] = refreshToken
+	return refreshToken, nil
+}
+
+func (s *MemoryStore) GetRefreshToken(ctx context.Context, tokenHash string) (RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	refreshToken, ok := s.refreshTokens[tokenHash]
+	if !ok {
+		return RefreshToken{}, sql.ErrNoRows
+	}
+	return refreshToken, nil
+}
+
+func (s *MemoryStore) RevokeToken(ctx context.Context, tokenHash string) (RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	refreshToken, ok := s.refreshTokens[tokenHash]
+	if !ok {
+		return RefreshToken{}, sql.ErrNoRows
+	}
+	now := s.timestamp()
+	refreshToken.RevokedAt = sql.NullTime{Time: now, Valid: true}
+	refreshToken.UpdatedAt = now
+	s.refreshTokens[tokenHash] = refreshToken
+	return refreshToken, nil
+}
+
+func (s *MemoryStore) RotateRefreshToken(ctx context.Context, arg RotateRefreshTokenParams) (RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	refreshToken, ok := s.refreshTokens[arg.TokenHash]
+	if !ok || refreshToken.RevokedAt.Valid {
+		return RefreshToken{}, sql.ErrNoRows
+	}
+	now := s.timestamp()
+	refreshToken.RevokedAt = sql.NullTime{Time: now, Valid: true}
+	refreshToken.UpdatedAt = now
+	refreshToken.ReplacedBy = arg.ReplacedBy
+	s.refreshTokens[arg.TokenHash] = refreshToken
+	return refreshToken, nil
+}
+
+func (s *MemoryStore) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.timestamp()
+	for tokenHash, refreshToken := range s.refreshTokens {
+		if refreshToken.FamilyID != familyID || refreshToken.RevokedAt.Valid {
+			continue
+		}
+		refreshToken.RevokedAt = sql.NullTime{Time: now, Valid: true}
+		refreshToken.UpdatedAt = now
+		s.refreshTokens[tokenHash] = refreshToken
+	}
+	return nil
+}
+
+func (s *MemoryStore) ListActiveRefreshTokensByUser(ctx context.Context, userID uuid.UUID) ([]RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.timestamp()
+	var items []RefreshToken
+	for _, refreshToken := range s.refreshTokens {
+		if refreshToken.UserID == userID && !refreshToken.RevokedAt.Valid && refreshToken.ExpiresAt.After(now) {
+			items = append(items, refreshToken)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].CreatedAt.After(items[j].CreatedAt)
+	})
+	return items, nil
+}
+
+func (s *MemoryStore) RevokeRefreshTokenByIdForUser(ctx context.Context, arg RevokeRefreshTokenByIdForUserParams) (RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for tokenHash, refreshToken := range s.refreshTokens {
+		if refreshToken.ID != arg.ID || refreshToken.UserID != arg.UserID || refreshToken.RevokedAt.Valid {
+			continue
+		}
+		now := s.timestamp()
+		refreshToken.RevokedAt = sql.NullTime{Time: now, Valid: true}
+		refreshToken.UpdatedAt = now
+		s.refreshTokens[tokenHash] = refreshToken
+		return refreshToken, nil
+	}
+	return RefreshToken{}, sql.ErrNoRows
+}
+
+func (s *MemoryStore) RevokeAllRefreshTokensForUser(ctx context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.timestamp()
+	for tokenHash, refreshToken := range s.refreshTokens {
+		if refreshToken.UserID != userID || refreshToken.RevokedAt.Valid {
+			continue
+		}
+		refreshToken.RevokedAt = sql.NullTime{Time: now, Valid: true}
+		refreshToken.UpdatedAt = now
+		s.refreshTokens[tokenHash] = refreshToken
+	}
+	return nil
+}
+
+func (s *MemoryStore) BanUser(ctx context.Context, id uuid.UUID) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return User{}, sql.ErrNoRows
+	}
+	now := s.timestamp()
+	user.BannedAt = sql.NullTime{Time: now, Valid: true}
+	user.UpdatedAt = now
+	s.users[user.ID] = user
+	return user, nil
+}
+
+func (s *MemoryStore) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.emailTaken(arg.Email, uuid.Nil) {
+		return User{}, uniqueViolation("users", "users_email_key")
+	}
+
+	now := s.timestamp()
+	user := User{
+		ID:             uuid.New(),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		Email:          arg.Email,
+		HashedPassword: arg.HashedPassword,
+		IsChirpyRed:    sql.NullBool{Bool: false, Valid: true},
+		Role:           "user",
+	}
+	s.users[user.ID] = user
+	return user, nil
+}
+
+func (s *MemoryStore) DeleteAllUsers(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id := range s.users {
+		s.deleteUser(id)
+	}
+	return nil
+}
+
+func (s *MemoryStore) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, user := range s.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return User{}, sql.ErrNoRows
+}
+
+func (s *MemoryStore) GetUserById(ctx context.Context, id uuid.UUID) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return User{}, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+func (s *MemoryStore) UnbanUser(ctx context.Context, id uuid.UUID) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return User{}, sql.ErrNoRows
+	}
+	user.BannedAt = sql.NullTime{}
+	user.UpdatedAt = s.timestamp()
+	s.users[user.ID] = user
+	return user, nil
+}
+
+func (s *MemoryStore) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[arg.ID]
+	if !ok {
+		return User{}, sql.ErrNoRows
+	}
+	if s.emailTaken(arg.Email, arg.ID) {
+		return User{}, uniqueViolation("users", "users_email_key")
+	}
+	if user.Email != arg.Email {
+		user.VerifiedAt = sql.NullTime{}
+	}
+	user.Email = arg.Email
+	user.HashedPassword = arg.HashedPassword
+	user.UpdatedAt = s.timestamp()
+	s.users[user.ID] = user
+	return user, nil
+}
+
+func (s *MemoryStore) UpdateUserIsChirpyRedById(ctx context.Context, arg UpdateUserIsChirpyRedByIdParams) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[arg.ID]
+	if !ok {
+		return User{}, sql.ErrNoRows
+	}
+	user.IsChirpyRed = arg.IsChirpyRed
+	user.UpdatedAt = s.timestamp()
+	s.users[user.ID] = user
+	return user, nil
+}
+
+func (s *MemoryStore) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[arg.ID]
+	if !ok {
+		return User{}, sql.ErrNoRows
+	}
+	user.HashedPassword = arg.HashedPassword
+	user.UpdatedAt = s.timestamp()
+	s.users[user.ID] = user
+	return user, nil
+}
+
+func (s *MemoryStore) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[arg.ID]
+	if !ok {
+		return User{}, sql.ErrNoRows
+	}
+	user.Role = arg.Role
+	user.UpdatedAt = s.timestamp()
+	s.users[user.ID] = user
+	return user, nil
+}
+
+func (s *MemoryStore) VerifyUserEmail(ctx context.Context, arg VerifyUserEmailParams) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[arg.ID]
+	if !ok || user.Email != arg.Email || user.VerifiedAt.Valid {
+		return User{}, sql.ErrNoRows
+	}
+	now := s.timestamp()
+	user.VerifiedAt = sql.NullTime{Time: now, Valid: true}
+	user.UpdatedAt = now
+	s.users[user.ID] = user
+	return user, nil
+}
+
+func (s *MemoryStore) SoftDeleteUser(ctx context.Context, id uuid.UUID) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok || user.DeletedAt.Valid {
+		return User{}, sql.ErrNoRows
+	}
+	now := s.timestamp()
+	user.DeletedAt = sql.NullTime{Time: now, Valid: true}
+	user.UpdatedAt = now
+	s.users[user.ID] = user
+	return user, nil
+}
+
+func (s *MemoryStore) RestoreUser(ctx context.Context, arg RestoreUserParams) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[arg.ID]
+	if !ok || !user.DeletedAt.Valid || !arg.DeletedAfter.Valid || !user.DeletedAt.Time.After(arg.DeletedAfter.Time) {
+		return User{}, sql.ErrNoRows
+	}
+	user.DeletedAt = sql.NullTime{}
+	user.UpdatedAt = s.timestamp()
+	s.users[user.ID] = user
+	return user, nil
+}
+
+func (s *MemoryStore) PurgeDeletedUsers(ctx context.Context, deletedBefore sql.NullTime) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for id, user := range s.users {
+		if user.DeletedAt.Valid && deletedBefore.Valid && !user.DeletedAt.Time.After(deletedBefore.Time) {
+			s.deleteUser(id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// chirpVisible reports whether a chirp's author still has an account, i.e.
+// has not deleted it.
+func (s *MemoryStore) chirpVisible(chirp Chirp) bool {
+	return !s.users[chirp.UserID].DeletedAt.Valid
+}
+
+// emailTaken reports whether a user other than except already uses email.
+func (s *MemoryStore) emailTaken(email string, except uuid.UUID) bool {
+	for _, user := range s.users {
+		if user.Email == email && user.ID != except {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteUser removes a user and every row that references it, like the
+// ON DELETE CASCADE foreign keys do.
+func (s *MemoryStore) deleteUser(id uuid.UUID) {
+	delete(s.users, id)
+	for chirpId, chirp := range s.chirps {
+		if chirp.UserID == id {
+			s.deleteChirp(chirpId)
+		}
+	}
+	for tokenHash, refreshToken := range s.refreshTokens {
+		if refreshToken.UserID == id {
+			delete(s.refreshTokens, tokenHash)
+		}
+	}
+	for tokenID, revokedToken := range s.revokedTokens {
+		if revokedToken.UserID == id {
+			delete(s.revokedTokens, tokenID)
+		}
+	}
+	for tokenHash, resetToken := range s.resetTokens {
+		if resetToken.UserID == id {
+			delete(s.resetTokens, tokenHash)
+		}
+	}
+	delete(s.totps, id)
+	for codeHash, recoveryCode := range s.recoveryCodes {
+		if recoveryCode.UserID == id {
+			delete(s.recoveryCodes, codeHash)
+		}
+	}
+	for apiKeyID, apiKey := range s.apiKeys {
+		if apiKey.UserID == id {
+			delete(s.apiKeys, apiKeyID)
+		}
+	}
+	for clientID, client := range s.oauthClients {
+		if client.OwnerID == id {
+			s.deleteOauthClient(clientID)
+		}
+	}
+	for codeHash, code := range s.oauthCodes {
+		if code.UserID == id {
+			delete(s.oauthCodes, codeHash)
+		}
+	}
+	for key, identity := range s.identities {
+		if identity.UserID == id {
+			delete(s.identities, key)
+		}
+	}
+	for key := range s.chirpLikes {
+		if key.userID == id {
+			s.deleteChirpLike(key)
+		}
+	}
+}
+
+// countReply adds delta to the reply count of the chirp that chirp replies
+// to, like the chirps_reply_count trigger. Deleted replies aren't counted.
+func (s *MemoryStore) countReply(chirp Chirp, delta int32) {
+	if !chirp.ReplyToID.Valid || chirp.DeletedAt.Valid {
+		return
+	}
+	if parent, ok := s.chirps[chirp.ReplyToID.UUID]; ok {
+		parent.ReplyCount += delta
+		s.chirps[parent.ID] = parent
+	}
+}
+
+// deleteChirp removes a chirp and every row that references it. Its replies
+// are kept but no longer reply to anything.
+func (s *MemoryStore) deleteChirp(id uuid.UUID) {
+	s.countReply(s.chirps[id], -1)
+	delete(s.chirps, id)
+	for replyID, reply := range s.chirps {
+		if reply.ReplyToID.Valid && reply.ReplyToID.UUID == id {
+			reply.ReplyToID = uuid.NullUUID{}
+			s.chirps[replyID] = reply
+		}
+	}
+	for decisionId, decision := range s.decisions {
+		if decision.ChirpID == id {
+			delete(s.decisions, decisionId)
+		}
+	}
+	for key := range s.chirpLikes {
+		if key.chirpID == id {
+			delete(s.chirpLikes, key)
+		}
+	}
+}
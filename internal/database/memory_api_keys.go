@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// apiKeyTouchInterval mirrors the interval used by TouchApiKey.
+const apiKeyTouchInterval = time.Minute
+
+func (s *MemoryStore) CreateApiKey(ctx context.Context, arg CreateApiKeyParams) (ApiKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[arg.UserID]; !ok {
+		return ApiKey{}, foreignKeyViolation("api_keys", "api_keys_user_id_fkey")
+	}
+	for _, apiKey := range s.apiKeys {
+		if apiKey.Prefix == arg.Prefix {
+			return ApiKey{}, uniqueViolation("api_keys", "api_keys_prefix_key")
+		}
+	}
+
+	apiKey := ApiKey{
+		ID:        uuid.New(),
+		UserID:    arg.UserID,
+		Name:      arg.Name,
+		Prefix:    arg.Prefix,
+		KeyHash:   arg.KeyHash,
+		Scopes:    slices.Clone(arg.Scopes),
+		CreatedAt: s.timestamp(),
+	}
+	s.apiKeys[apiKey.ID] = apiKey
+	return apiKey, nil
+}
+
+func (s *MemoryStore) GetActiveApiKeyByPrefix(ctx context.Context, prefix string) (ApiKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, apiKey := range s.apiKeys {
+		if apiKey.Prefix != prefix || apiKey.RevokedAt.Valid {
+			continue
+		}
+		user := s.users[apiKey.UserID]
+		if user.BannedAt.Valid || user.DeletedAt.Valid {
+			break
+		}
+		apiKey.Scopes = slices.Clone(apiKey.Scopes)
+		return apiKey, nil
+	}
+	return ApiKey{}, sql.ErrNoRows
+}
+
+func (s *MemoryStore) ListActiveApiKeysByUser(ctx context.Context, userID uuid.UUID) ([]ApiKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var apiKeys []ApiKey
+	for _, apiKey := range s.apiKeys {
+		if apiKey.UserID == userID && !apiKey.RevokedAt.Valid {
+			apiKey.Scopes = slices.Clone(apiKey.Scopes)
+			apiKeys = append(apiKeys, apiKey)
+		}
+	}
+	sort.Slice(apiKeys, func(i, j int) bool {
+		return apiKeys[i].CreatedAt.After(apiKeys[j].CreatedAt)
+	})
+	return apiKeys, nil
+}
+
+func (s *MemoryStore) RevokeApiKeyForUser(ctx context.Context, arg RevokeApiKeyForUserParams) (ApiKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	apiKey, ok := s.apiKeys[arg.ID]
+	if !ok || apiKey.UserID != arg.UserID || apiKey.RevokedAt.Valid {
+		return ApiKey{}, sql.ErrNoRows
+	}
+	apiKey.RevokedAt = sql.NullTime{Time: s.timestamp(), Valid: true}
+	s.apiKeys[arg.ID] = apiKey
+	apiKey.Scopes = slices.Clone(apiKey.Scopes)
+	return apiKey, nil
+}
+
+func (s *MemoryStore) TouchApiKey(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	apiKey, ok := s.apiKeys[id]
+	if !ok {
+		return nil
+	}
+	now := s.timestamp()
+	if apiKey.LastUsedAt.Valid && !apiKey.LastUsedAt.Time.Before(now.Add(-apiKeyTouchInterval)) {
+		return nil
+	}
+	apiKey.LastUsedAt = sql.NullTime{Time: now, Valid: true}
+	s.apiKeys[id] = apiKey
+	return nil
+}
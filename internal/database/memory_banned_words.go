@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+func (s *MemoryStore) ListBannedWords(ctx context.Context) ([]BannedWord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []BannedWord
+	for _, bannedWord := range s.bannedWords {
+		items = append(items, bannedWord)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Word < items[j].Word
+	})
+	return items, nil
+}
+
+func (s *MemoryStore) UpsertBannedWord(ctx context.Context, arg UpsertBannedWordParams) (BannedWord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.timestamp()
+	bannedWord, ok := s.bannedWords[arg.Word]
+	if ok {
+		bannedWord.Action = arg.Action
+		bannedWord.UpdatedAt = now
+	} else {
+		bannedWord = BannedWord{
+			Word:      arg.Word,
+			Action:    arg.Action,
+			CreatedAt: now,
+			UpdatedAt: now,
+			CreatedBy: arg.CreatedBy,
+		}
+	}
+	s.bannedWords[arg.Word] = bannedWord
+	return bannedWord, nil
+}
+
+func (s *MemoryStore) DeleteBannedWord(ctx context.Context, word string) (BannedWord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bannedWord, ok := s.bannedWords[word]
+	if !ok {
+		return BannedWord{}, sql.ErrNoRows
+	}
+	delete(s.bannedWords, word)
+	return bannedWord, nil
+}
+
+func (s *MemoryStore) CreateBannedWordChange(ctx context.Context, arg CreateBannedWordChangeParams) (BannedWordChange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	change := BannedWordChange{
+		ID:        uuid.New(),
+		Word:      arg.Word,
+		Change:    arg.Change,
+		Action:    arg.Action,
+		ChangedBy: arg.ChangedBy,
+		ChangedAt: s.timestamp(),
+	}
+	s.bannedWordChanges = append(s.bannedWordChanges, change)
+	return change, nil
+}
+
+func (s *MemoryStore) ListBannedWordChanges(ctx context.Context) ([]BannedWordChange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []BannedWordChange
+	for i := len(s.bannedWordChanges) - 1; i >= 0; i-- {
+		items = append(items, s.bannedWordChanges[i])
+	}
+	return items, nil
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// chirpLikeKey is the primary key of chirp_likes.
+type chirpLikeKey struct {
+	chirpID uuid.UUID
+	userID  uuid.UUID
+}
+
+func (s *MemoryStore) LikeChirp(ctx context.Context, arg LikeChirpParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chirp, ok := s.chirps[arg.ChirpID]
+	if !ok {
+		return foreignKeyViolation("chirp_likes", "chirp_likes_chirp_id_fkey")
+	}
+	if _, ok := s.users[arg.UserID]; !ok {
+		return foreignKeyViolation("chirp_likes", "chirp_likes_user_id_fkey")
+	}
+	key := chirpLikeKey{chirpID: arg.ChirpID, userID: arg.UserID}
+	if _, ok := s.chirpLikes[key]; ok {
+		return nil
+	}
+
+	s.chirpLikes[key] = ChirpLike{
+		ChirpID:   arg.ChirpID,
+		UserID:    arg.UserID,
+		CreatedAt: s.timestamp(),
+	}
+	// what the chirp_likes_count trigger does
+	chirp.LikeCount++
+	s.chirps[chirp.ID] = chirp
+	return nil
+}
+
+func (s *MemoryStore) ListLikedChirpIDs(ctx context.Context, arg ListLikedChirpIDsParams) ([]uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []uuid.UUID
+	for _, chirpID := range arg.ChirpIds {
+		if _, ok := s.chirpLikes[chirpLikeKey{chirpID: chirpID, userID: arg.UserID}]; ok {
+			items = append(items, chirpID)
+		}
+	}
+	return items, nil
+}
+
+func (s *MemoryStore) UnlikeChirp(ctx context.Context, arg UnlikeChirpParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deleteChirpLike(chirpLikeKey{chirpID: arg.ChirpID, userID: arg.UserID})
+	return nil
+}
+
+// deleteChirpLike removes a like, if there is one, and takes it off its
+// chirp's like count.
+func (s *MemoryStore) deleteChirpLike(key chirpLikeKey) {
+	if _, ok := s.chirpLikes[key]; !ok {
+		return
+	}
+	delete(s.chirpLikes, key)
+	if chirp, ok := s.chirps[key.chirpID]; ok {
+		chirp.LikeCount--
+		s.chirps[chirp.ID] = chirp
+	}
+}
@@ -0,0 +1,148 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// oauthAuthorizationCodeLifetime mirrors the interval used by
+// CreateOauthAuthorizationCode.
+const oauthAuthorizationCodeLifetime = 5 * time.Minute
+
+func (s *MemoryStore) CreateOauthClient(ctx context.Context, arg CreateOauthClientParams) (OauthClient, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[arg.OwnerID]; !ok {
+		return OauthClient{}, foreignKeyViolation("oauth_clients", "oauth_clients_owner_id_fkey")
+	}
+
+	client := OauthClient{
+		ID:           uuid.New(),
+		OwnerID:      arg.OwnerID,
+		Name:         arg.Name,
+		RedirectUris: slices.Clone(arg.RedirectUris),
+		SecretHash:   arg.SecretHash,
+		CreatedAt:    s.timestamp(),
+	}
+	s.oauthClients[client.ID] = client
+	return client, nil
+}
+
+func (s *MemoryStore) GetOauthClient(ctx context.Context, id uuid.UUID) (OauthClient, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	client, ok := s.oauthClients[id]
+	if !ok {
+		return OauthClient{}, sql.ErrNoRows
+	}
+	client.RedirectUris = slices.Clone(client.RedirectUris)
+	return client, nil
+}
+
+func (s *MemoryStore) ListOauthClientsByOwner(ctx context.Context, ownerID uuid.UUID) ([]OauthClient, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var clients []OauthClient
+	for _, client := range s.oauthClients {
+		if client.OwnerID == ownerID {
+			client.RedirectUris = slices.Clone(client.RedirectUris)
+			clients = append(clients, client)
+		}
+	}
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].CreatedAt.After(clients[j].CreatedAt)
+	})
+	return clients, nil
+}
+
+func (s *MemoryStore) DeleteOauthClientForOwner(ctx context.Context, arg DeleteOauthClientForOwnerParams) (OauthClient, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	client, ok := s.oauthClients[arg.ID]
+	if !ok || client.OwnerID != arg.OwnerID {
+		return OauthClient{}, sql.ErrNoRows
+	}
+	s.deleteOauthClient(arg.ID)
+	return client, nil
+}
+
+func (s *MemoryStore) CreateOauthAuthorizationCode(ctx context.Context, arg CreateOauthAuthorizationCodeParams) (OauthAuthorizationCode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.oauthClients[arg.ClientID]; !ok {
+		return OauthAuthorizationCode{}, foreignKeyViolation("oauth_authorization_codes", "oauth_authorization_codes_client_id_fkey")
+	}
+	if _, ok := s.users[arg.UserID]; !ok {
+		return OauthAuthorizationCode{}, foreignKeyViolation("oauth_authorization_codes", "oauth_authorization_codes_user_id_fkey")
+	}
+	if _, ok := s.oauthCodes[arg.CodeHash]; ok {
+		return OauthAuthorizationCode{}, uniqueViolation("oauth_authorization_codes", "oauth_authorization_codes_pkey")
+	}
+
+	now := s.timestamp()
+	code := OauthAuthorizationCode{
+		CodeHash:      arg.CodeHash,
+		ClientID:      arg.ClientID,
+		UserID:        arg.UserID,
+		RedirectUri:   arg.RedirectUri,
+		Scopes:        slices.Clone(arg.Scopes),
+		CodeChallenge: arg.CodeChallenge,
+		FamilyID:      arg.FamilyID,
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(oauthAuthorizationCodeLifetime),
+	}
+	s.oauthCodes[code.CodeHash] = code
+	return code, nil
+}
+
+func (s *MemoryStore) UseOauthAuthorizationCode(ctx context.Context, codeHash string) (OauthAuthorizationCode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.timestamp()
+	code, ok := s.oauthCodes[codeHash]
+	if !ok || code.UsedAt.Valid || !code.ExpiresAt.After(now) {
+		return OauthAuthorizationCode{}, sql.ErrNoRows
+	}
+	code.UsedAt = sql.NullTime{Time: now, Valid: true}
+	s.oauthCodes[codeHash] = code
+	code.Scopes = slices.Clone(code.Scopes)
+	return code, nil
+}
+
+func (s *MemoryStore) GetOauthAuthorizationCode(ctx context.Context, codeHash string) (OauthAuthorizationCode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	code, ok := s.oauthCodes[codeHash]
+	if !ok {
+		return OauthAuthorizationCode{}, sql.ErrNoRows
+	}
+	code.Scopes = slices.Clone(code.Scopes)
+	return code, nil
+}
+
+// deleteOauthClient removes a client and every row that references it.
+func (s *MemoryStore) deleteOauthClient(id uuid.UUID) {
+	delete(s.oauthClients, id)
+	for codeHash, code := range s.oauthCodes {
+		if code.ClientID == id {
+			delete(s.oauthCodes, codeHash)
+		}
+	}
+	for tokenHash, refreshToken := range s.refreshTokens {
+		if refreshToken.ClientID.Valid && refreshToken.ClientID.UUID == id {
+			delete(s.refreshTokens, tokenHash)
+		}
+	}
+}
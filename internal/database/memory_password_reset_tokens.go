@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+func (s *MemoryStore) CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) (PasswordResetToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[arg.UserID]; !ok {
+		return PasswordResetToken{}, foreignKeyViolation("password_reset_tokens", "password_reset_tokens_user_id_fkey")
+	}
+	if _, ok := s.resetTokens[arg.TokenHash]; ok {
+		return PasswordResetToken{}, uniqueViolation("password_reset_tokens", "password_reset_tokens_pkey")
+	}
+
+	now := s.timestamp()
+	resetToken := PasswordResetToken{
+		TokenHash: arg.TokenHash,
+		UserID:    arg.UserID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(passwordResetTokenLifetime),
+	}
+	s.resetTokens[resetToken.TokenHash] = resetToken
+	return resetToken, nil
+}
+
+func (s *MemoryStore) UsePasswordResetToken(ctx context.Context, tokenHash string) (PasswordResetToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.timestamp()
+	resetToken, ok := s.resetTokens[tokenHash]
+	if !ok || resetToken.UsedAt.Valid || !resetToken.ExpiresAt.After(now) {
+		return PasswordResetToken{}, sql.ErrNoRows
+	}
+	resetToken.UsedAt = sql.NullTime{Time: now, Valid: true}
+	s.resetTokens[tokenHash] = resetToken
+	return resetToken, nil
+}
+
+func (s *MemoryStore) InvalidatePasswordResetTokensForUser(ctx context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.timestamp()
+	for tokenHash, resetToken := range s.resetTokens {
+		if resetToken.UserID != userID || resetToken.UsedAt.Valid {
+			continue
+		}
+		resetToken.UsedAt = sql.NullTime{Time: now, Valid: true}
+		s.resetTokens[tokenHash] = resetToken
+	}
+	return nil
+}
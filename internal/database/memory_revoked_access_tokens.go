@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+func (s *MemoryStore) IsAccessTokenRevoked(ctx context.Context, id uuid.UUID) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.revokedTokens[id]
+	return ok, nil
+}
+
+func (s *MemoryStore) RevokeAccessTokensForClient(ctx context.Context, clientID uuid.NullUUID) ([]RevokedAccessToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.revokeAccessTokens(func(refreshToken RefreshToken) bool {
+		return clientID.Valid && refreshToken.ClientID == clientID
+	}), nil
+}
+
+func (s *MemoryStore) RevokeAccessTokensForFamily(ctx context.Context, familyID uuid.UUID) ([]RevokedAccessToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.revokeAccessTokens(func(refreshToken RefreshToken) bool {
+		return refreshToken.FamilyID == familyID
+	}), nil
+}
+
+func (s *MemoryStore) RevokeAccessTokensForUser(ctx context.Context, userID uuid.UUID) ([]RevokedAccessToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.revokeAccessTokens(func(refreshToken RefreshToken) bool {
+		return refreshToken.UserID == userID
+	}), nil
+}
+
+func (s *MemoryStore) DeleteExpiredRevokedAccessTokens(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.timestamp()
+	for id, revokedToken := range s.revokedTokens {
+		if !revokedToken.ExpiresAt.After(now) {
+			delete(s.revokedTokens, id)
+		}
+	}
+	return nil
+}
+
+// revokeAccessTokens denylists the unexpired access tokens issued with the
+// refresh tokens matching match. Like ON CONFLICT DO NOTHING, tokens that
+// are already revoked are skipped and not returned.
+func (s *MemoryStore) revokeAccessTokens(match func(RefreshToken) bool) []RevokedAccessToken {
+	now := s.timestamp()
+	var items []RevokedAccessToken
+	for _, refreshToken := range s.refreshTokens {
+		if !match(refreshToken) || !refreshToken.AccessTokenID.Valid || !refreshToken.AccessTokenExpiresAt.Time.After(now) {
+			continue
+		}
+		if _, ok := s.revokedTokens[refreshToken.AccessTokenID.UUID]; ok {
+			continue
+		}
+		revokedToken := RevokedAccessToken{
+			ID:        refreshToken.AccessTokenID.UUID,
+			UserID:    refreshToken.UserID,
+			ExpiresAt: refreshToken.AccessTokenExpiresAt.Time,
+			RevokedAt: now,
+		}
+		s.revokedTokens[revokedToken.ID] = revokedToken
+		items = append(items, revokedToken)
+	}
+	return items
+}
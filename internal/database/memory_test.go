@@ -0,0 +1,764 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+func TestMemoryStoreConstraints(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+
+	user, err := s.CreateUser(ctx, CreateUserParams{Email: "a@example.com", HashedPassword: "hash"})
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		run      func() error
+		wantCode pq.ErrorCode
+	}{
+		{
+			name: "Duplicate email",
+			run: func() error {
+				_, err := s.CreateUser(ctx, CreateUserParams{Email: "a@example.com", HashedPassword: "hash"})
+				return err
+			},
+			wantCode: "23505",
+		},
+		{
+			name: "Duplicate chirp body",
+			run: func() error {
+				if _, err := s.CreateChirp(ctx, CreateChirpParams{UserID: user.ID, Body: "hello"}); err != nil {
+					return err
+				}
+				_, err := s.CreateChirp(ctx, CreateChirpParams{UserID: user.ID, Body: "hello"})
+				return err
+			},
+			wantCode: "23505",
+		},
+		{
+			name: "Chirp for unknown user",
+			run: func() error {
+				_, err := s.CreateChirp(ctx, CreateChirpParams{Body: "orphan"})
+				return err
+			},
+			wantCode: "23503",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pqErr *pq.Error
+			err := tt.run()
+			if !errors.As(err, &pqErr) || pqErr.Code != tt.wantCode {
+				t.Errorf("error = %v, want SQLSTATE %s", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreCascadeDelete(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+
+	user, _ := s.CreateUser(ctx, CreateUserParams{Email: "a@example.com", HashedPassword: "hash"})
+	chirp, _ := s.CreateChirp(ctx, CreateChirpParams{UserID: user.ID, Body: "hello"})
+	s.CreateRefreshToken(ctx, CreateRefreshTokenParams{UserID: user.ID, TokenHash: "token"})
+
+	if err := s.DeleteAllUsers(ctx); err != nil {
+		t.Fatalf("DeleteAllUsers() error = %v", err)
+	}
+	if _, err := s.GetChirpById(ctx, chirp.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetChirpById() error = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := s.GetRefreshToken(ctx, "token"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetRefreshToken() error = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestMemoryStoreInTx(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	user, _ := s.CreateUser(ctx, CreateUserParams{Email: "a@example.com", HashedPassword: "hash"})
+
+	err := s.InTx(ctx, func(q Querier) error {
+		chirp, err := q.CreateChirp(ctx, CreateChirpParams{UserID: user.ID, Body: "kept"})
+		if err != nil {
+			return err
+		}
+		_, err = q.CreateChirpModerationDecision(ctx, CreateChirpModerationDecisionParams{ChirpID: chirp.ID, Rule: "rule", Action: "flag"})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("InTx() error = %v", err)
+	}
+
+	errRollback := errors.New("rollback")
+	err = s.InTx(ctx, func(q Querier) error {
+		q.CreateChirp(ctx, CreateChirpParams{UserID: user.ID, Body: "dropped"})
+		q.UpsertBannedWord(ctx, UpsertBannedWordParams{Word: "dropped", Action: "mask", CreatedBy: "admin"})
+		q.CreateBannedWordChange(ctx, CreateBannedWordChangeParams{Word: "dropped", Change: "add", Action: "mask", ChangedBy: "admin"})
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("InTx() error = %v, want %v", err, errRollback)
+	}
+
+	chirps, _ := s.GetAllChirps(ctx, GetAllChirpsParams{Sort: "ASC", RowLimit: 10})
+	if len(chirps) != 1 || chirps[0].Body != "kept" {
+		t.Fatalf("chirps after InTx() = %v, want only the committed one", chirps)
+	}
+	if decisions, _ := s.GetChirpModerationDecisions(ctx, chirps[0].ID); len(decisions) != 1 {
+		t.Errorf("GetChirpModerationDecisions() = %v, want the committed decision", decisions)
+	}
+	words, _ := s.ListBannedWords(ctx)
+	changes, _ := s.ListBannedWordChanges(ctx)
+	if len(words) != 0 || len(changes) != 0 {
+		t.Errorf("banned words after a rolled back InTx() = %v, %v, want none", words, changes)
+	}
+}
+
+func TestMemoryStoreRefreshToken(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+
+	user, _ := s.CreateUser(ctx, CreateUserParams{Email: "a@example.com", HashedPassword: "hash"})
+	created, err := s.CreateRefreshToken(ctx, CreateRefreshTokenParams{UserID: user.ID, TokenHash: "token"})
+	if err != nil {
+		t.Fatalf("CreateRefreshToken() error = %v", err)
+	}
+	if got := created.ExpiresAt.Sub(created.CreatedAt); got != refreshTokenLifetime {
+		t.Errorf("token lifetime = %v, want %v", got, refreshTokenLifetime)
+	}
+
+	revoked, err := s.RevokeToken(ctx, "token")
+	if err != nil {
+		t.Fatalf("RevokeToken() error = %v", err)
+	}
+	if !revoked.RevokedAt.Valid {
+		t.Errorf("RevokeToken() did not set revoked_at")
+	}
+	if _, err := s.RevokeToken(ctx, "missing"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("RevokeToken() error = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestMemoryStoreVerifyUserEmail(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+
+	user, _ := s.CreateUser(ctx, CreateUserParams{Email: "a@example.com", HashedPassword: "hash"})
+	if user.VerifiedAt.Valid {
+		t.Fatalf("CreateUser() returned a verified user")
+	}
+
+	verified, err := s.VerifyUserEmail(ctx, VerifyUserEmailParams{ID: user.ID, Email: "a@example.com"})
+	if err != nil {
+		t.Fatalf("VerifyUserEmail() error = %v", err)
+	}
+	if !verified.VerifiedAt.Valid {
+		t.Errorf("VerifyUserEmail() did not set verified_at")
+	}
+	if _, err := s.VerifyUserEmail(ctx, VerifyUserEmailParams{ID: user.ID, Email: "a@example.com"}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("VerifyUserEmail() of a verified address error = %v, want sql.ErrNoRows", err)
+	}
+
+	// keeping the address keeps the verification, changing it drops it
+	updated, _ := s.UpdateUser(ctx, UpdateUserParams{ID: user.ID, Email: "a@example.com", HashedPassword: "new"})
+	if !updated.VerifiedAt.Valid {
+		t.Errorf("UpdateUser() cleared verified_at without an email change")
+	}
+	updated, _ = s.UpdateUser(ctx, UpdateUserParams{ID: user.ID, Email: "b@example.com", HashedPassword: "new"})
+	if updated.VerifiedAt.Valid {
+		t.Errorf("UpdateUser() kept verified_at after an email change")
+	}
+
+	if _, err := s.VerifyUserEmail(ctx, VerifyUserEmailParams{ID: user.ID, Email: "a@example.com"}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("VerifyUserEmail() for the old address error = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestMemoryStorePasswordResetToken(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	clock := time.Now()
+	s.now = func() time.Time { return clock }
+
+	user, _ := s.CreateUser(ctx, CreateUserParams{Email: "a@example.com", HashedPassword: "hash"})
+	for _, tokenHash := range []string{"once", "other"} {
+		if _, err := s.CreatePasswordResetToken(ctx, CreatePasswordResetTokenParams{TokenHash: tokenHash, UserID: user.ID}); err != nil {
+			t.Fatalf("CreatePasswordResetToken() error = %v", err)
+		}
+	}
+
+	if _, err := s.UsePasswordResetToken(ctx, "once"); err != nil {
+		t.Fatalf("UsePasswordResetToken() error = %v", err)
+	}
+	if _, err := s.UsePasswordResetToken(ctx, "once"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("UsePasswordResetToken() reused token error = %v, want sql.ErrNoRows", err)
+	}
+
+	if err := s.InvalidatePasswordResetTokensForUser(ctx, user.ID); err != nil {
+		t.Fatalf("InvalidatePasswordResetTokensForUser() error = %v", err)
+	}
+	if _, err := s.UsePasswordResetToken(ctx, "other"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("UsePasswordResetToken() invalidated token error = %v, want sql.ErrNoRows", err)
+	}
+
+	s.CreatePasswordResetToken(ctx, CreatePasswordResetTokenParams{TokenHash: "late", UserID: user.ID})
+	clock = clock.Add(passwordResetTokenLifetime)
+	if _, err := s.UsePasswordResetToken(ctx, "late"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("UsePasswordResetToken() expired token error = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestMemoryStoreRevokeAccessTokens(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+
+	user, _ := s.CreateUser(ctx, CreateUserParams{Email: "a@example.com", HashedPassword: "hash"})
+	familyID := uuid.New()
+	accessTokenID := uuid.New()
+	expiresAt := s.timestamp().Add(time.Hour)
+	for i, tokenHash := range []string{"token", "other"} {
+		arg := CreateRefreshTokenParams{UserID: user.ID, TokenHash: tokenHash, FamilyID: uuid.New()}
+		if i == 0 {
+			arg.FamilyID = familyID
+			arg.AccessTokenID = uuid.NullUUID{UUID: accessTokenID, Valid: true}
+			arg.AccessTokenExpiresAt = sql.NullTime{Time: expiresAt, Valid: true}
+		}
+		if _, err := s.CreateRefreshToken(ctx, arg); err != nil {
+			t.Fatalf("CreateRefreshToken() error = %v", err)
+		}
+	}
+
+	revoked, err := s.RevokeAccessTokensForFamily(ctx, familyID)
+	if err != nil {
+		t.Fatalf("RevokeAccessTokensForFamily() error = %v", err)
+	}
+	if len(revoked) != 1 || revoked[0].ID != accessTokenID || !revoked[0].ExpiresAt.Equal(expiresAt) {
+		t.Errorf("RevokeAccessTokensForFamily() = %+v, want access token %v", revoked, accessTokenID)
+	}
+	if ok, _ := s.IsAccessTokenRevoked(ctx, accessTokenID); !ok {
+		t.Errorf("IsAccessTokenRevoked() = false after revocation")
+	}
+
+	// already revoked tokens are skipped, like ON CONFLICT DO NOTHING
+	if revoked, _ := s.RevokeAccessTokensForUser(ctx, user.ID); len(revoked) != 0 {
+		t.Errorf("RevokeAccessTokensForUser() = %+v, want nothing new", revoked)
+	}
+
+	s.now = func() time.Time { return expiresAt.Add(time.Second) }
+	if err := s.DeleteExpiredRevokedAccessTokens(ctx); err != nil {
+		t.Fatalf("DeleteExpiredRevokedAccessTokens() error = %v", err)
+	}
+	if ok, _ := s.IsAccessTokenRevoked(ctx, accessTokenID); ok {
+		t.Errorf("IsAccessTokenRevoked() = true after the token expired and was purged")
+	}
+}
+
+func TestMemoryStoreSoftDeleteUser(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	clock := time.Now()
+	s.now = func() time.Time { return clock }
+
+	user, _ := s.CreateUser(ctx, CreateUserParams{Email: "a@example.com", HashedPassword: "hash"})
+	chirp, _ := s.CreateChirp(ctx, CreateChirpParams{UserID: user.ID, Body: "hello"})
+
+	if _, err := s.SoftDeleteUser(ctx, user.ID); err != nil {
+		t.Fatalf("SoftDeleteUser() error = %v", err)
+	}
+	if _, err := s.GetChirpById(ctx, chirp.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetChirpById() error = %v, want sql.ErrNoRows for a deleted author", err)
+	}
+	if chirps, _ := s.GetAllChirps(ctx, GetAllChirpsParams{Sort: "ASC", RowLimit: 10}); len(chirps) != 0 {
+		t.Errorf("GetAllChirps() = %d chirps, want deleted authors hidden", len(chirps))
+	}
+
+	gracePeriod := 24 * time.Hour
+	clock = clock.Add(time.Hour)
+	deletedAfter := sql.NullTime{Time: s.timestamp().Add(-gracePeriod), Valid: true}
+	if _, err := s.RestoreUser(ctx, RestoreUserParams{ID: user.ID, DeletedAfter: deletedAfter}); err != nil {
+		t.Fatalf("RestoreUser() error = %v", err)
+	}
+	if _, err := s.GetChirpById(ctx, chirp.ID); err != nil {
+		t.Errorf("GetChirpById() error = %v after restoring the author", err)
+	}
+
+	s.SoftDeleteUser(ctx, user.ID)
+	clock = clock.Add(gracePeriod + time.Hour)
+	deletedBefore := sql.NullTime{Time: s.timestamp().Add(-gracePeriod), Valid: true}
+	if _, err := s.RestoreUser(ctx, RestoreUserParams{ID: user.ID, DeletedAfter: deletedBefore}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("RestoreUser() after the grace period error = %v, want sql.ErrNoRows", err)
+	}
+	purged, err := s.PurgeDeletedUsers(ctx, deletedBefore)
+	if err != nil || purged != 1 {
+		t.Fatalf("PurgeDeletedUsers() = %d, %v, want 1", purged, err)
+	}
+	if _, err := s.GetUserById(ctx, user.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetUserById() error = %v after purge, want sql.ErrNoRows", err)
+	}
+	if len(s.chirps) != 0 {
+		t.Errorf("purge left %d chirps, want them cascaded", len(s.chirps))
+	}
+}
+
+func TestMemoryStoreUserTotp(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+
+	user, _ := s.CreateUser(ctx, CreateUserParams{Email: "a@example.com", HashedPassword: "hash"})
+	if _, err := s.UpsertUserTotp(ctx, UpsertUserTotpParams{UserID: user.ID, Secret: "first"}); err != nil {
+		t.Fatalf("UpsertUserTotp() error = %v", err)
+	}
+	// an unconfirmed enrollment can be restarted
+	if totp, err := s.UpsertUserTotp(ctx, UpsertUserTotpParams{UserID: user.ID, Secret: "second"}); err != nil || totp.Secret != "second" {
+		t.Fatalf("UpsertUserTotp() = %+v, %v, want the new secret", totp, err)
+	}
+	if _, err := s.UseTotpStep(ctx, UseTotpStepParams{UserID: user.ID, LastUsedStep: 1}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("UseTotpStep() before confirmation error = %v, want sql.ErrNoRows", err)
+	}
+
+	if _, err := s.ConfirmUserTotp(ctx, ConfirmUserTotpParams{UserID: user.ID, LastUsedStep: 10}); err != nil {
+		t.Fatalf("ConfirmUserTotp() error = %v", err)
+	}
+	if _, err := s.UpsertUserTotp(ctx, UpsertUserTotpParams{UserID: user.ID, Secret: "third"}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("UpsertUserTotp() once confirmed error = %v, want sql.ErrNoRows", err)
+	}
+
+	// codes of a step already used are replays
+	if _, err := s.UseTotpStep(ctx, UseTotpStepParams{UserID: user.ID, LastUsedStep: 10}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("UseTotpStep() of a used step error = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := s.UseTotpStep(ctx, UseTotpStepParams{UserID: user.ID, LastUsedStep: 11}); err != nil {
+		t.Errorf("UseTotpStep() of a new step error = %v", err)
+	}
+
+	err := s.CreateTotpRecoveryCodes(ctx, CreateTotpRecoveryCodesParams{UserID: user.ID, CodeHashes: []string{"a", "b"}})
+	if err != nil {
+		t.Fatalf("CreateTotpRecoveryCodes() error = %v", err)
+	}
+	if _, err := s.UseTotpRecoveryCode(ctx, UseTotpRecoveryCodeParams{UserID: user.ID, CodeHash: "a"}); err != nil {
+		t.Errorf("UseTotpRecoveryCode() error = %v", err)
+	}
+	if _, err := s.UseTotpRecoveryCode(ctx, UseTotpRecoveryCodeParams{UserID: user.ID, CodeHash: "a"}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("UseTotpRecoveryCode() reused error = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := s.UseTotpRecoveryCode(ctx, UseTotpRecoveryCodeParams{UserID: uuid.New(), CodeHash: "b"}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("UseTotpRecoveryCode() of another user error = %v, want sql.ErrNoRows", err)
+	}
+
+	s.DeleteAllUsers(ctx)
+	if _, err := s.GetUserTotp(ctx, user.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetUserTotp() after user deletion error = %v, want sql.ErrNoRows", err)
+	}
+	if len(s.recoveryCodes) != 0 {
+		t.Errorf("recovery codes left after user deletion: %v", s.recoveryCodes)
+	}
+}
+
+func TestMemoryStoreApiKeys(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+
+	user, _ := s.CreateUser(ctx, CreateUserParams{Email: "a@example.com", HashedPassword: "hash"})
+	arg := CreateApiKeyParams{UserID: user.ID, Name: "bot", Prefix: "abc", KeyHash: "hash", Scopes: []string{"chirps:write"}}
+	apiKey, err := s.CreateApiKey(ctx, arg)
+	if err != nil {
+		t.Fatalf("CreateApiKey() error = %v", err)
+	}
+	var pqErr *pq.Error
+	if _, err := s.CreateApiKey(ctx, arg); !errors.As(err, &pqErr) || pqErr.Code != "23505" {
+		t.Errorf("CreateApiKey() with a taken prefix error = %v, want SQLSTATE 23505", err)
+	}
+
+	if got, err := s.GetActiveApiKeyByPrefix(ctx, "abc"); err != nil || got.ID != apiKey.ID {
+		t.Errorf("GetActiveApiKeyByPrefix() = %+v, %v, want %v", got, err, apiKey.ID)
+	}
+
+	// keys stop working while their user is banned
+	s.BanUser(ctx, user.ID)
+	if _, err := s.GetActiveApiKeyByPrefix(ctx, "abc"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetActiveApiKeyByPrefix() of a banned user error = %v, want sql.ErrNoRows", err)
+	}
+	s.UnbanUser(ctx, user.ID)
+
+	now := s.timestamp()
+	s.now = func() time.Time { return now }
+	s.TouchApiKey(ctx, apiKey.ID)
+	s.now = func() time.Time { return now.Add(time.Second) }
+	s.TouchApiKey(ctx, apiKey.ID)
+	if got, _ := s.GetActiveApiKeyByPrefix(ctx, "abc"); !got.LastUsedAt.Time.Equal(now) {
+		t.Errorf("LastUsedAt = %v, want %v, written at most once a minute", got.LastUsedAt.Time, now)
+	}
+
+	if _, err := s.RevokeApiKeyForUser(ctx, RevokeApiKeyForUserParams{ID: apiKey.ID, UserID: uuid.New()}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("RevokeApiKeyForUser() by another user error = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := s.RevokeApiKeyForUser(ctx, RevokeApiKeyForUserParams{ID: apiKey.ID, UserID: user.ID}); err != nil {
+		t.Fatalf("RevokeApiKeyForUser() error = %v", err)
+	}
+	if _, err := s.GetActiveApiKeyByPrefix(ctx, "abc"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetActiveApiKeyByPrefix() of a revoked key error = %v, want sql.ErrNoRows", err)
+	}
+	if apiKeys, _ := s.ListActiveApiKeysByUser(ctx, user.ID); len(apiKeys) != 0 {
+		t.Errorf("ListActiveApiKeysByUser() = %+v, want no revoked keys", apiKeys)
+	}
+}
+
+func TestMemoryStoreOauth(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+
+	owner, _ := s.CreateUser(ctx, CreateUserParams{Email: "a@example.com", HashedPassword: "hash"})
+	user, _ := s.CreateUser(ctx, CreateUserParams{Email: "b@example.com", HashedPassword: "hash"})
+	client, err := s.CreateOauthClient(ctx, CreateOauthClientParams{OwnerID: owner.ID, Name: "app", RedirectUris: []string{"https://app.example.com/callback"}})
+	if err != nil {
+		t.Fatalf("CreateOauthClient() error = %v", err)
+	}
+
+	arg := CreateOauthAuthorizationCodeParams{
+		CodeHash:      "code",
+		ClientID:      client.ID,
+		UserID:        user.ID,
+		RedirectUri:   "https://app.example.com/callback",
+		Scopes:        []string{"chirps:write"},
+		CodeChallenge: "challenge",
+		FamilyID:      uuid.New(),
+	}
+	if _, err := s.CreateOauthAuthorizationCode(ctx, arg); err != nil {
+		t.Fatalf("CreateOauthAuthorizationCode() error = %v", err)
+	}
+	if _, err := s.UseOauthAuthorizationCode(ctx, "code"); err != nil {
+		t.Fatalf("UseOauthAuthorizationCode() error = %v", err)
+	}
+	if _, err := s.UseOauthAuthorizationCode(ctx, "code"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("UseOauthAuthorizationCode() twice error = %v, want sql.ErrNoRows", err)
+	}
+
+	// codes expire after five minutes
+	arg.CodeHash = "expired"
+	s.CreateOauthAuthorizationCode(ctx, arg)
+	now := s.timestamp()
+	s.now = func() time.Time { return now.Add(oauthAuthorizationCodeLifetime) }
+	if _, err := s.UseOauthAuthorizationCode(ctx, "expired"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("UseOauthAuthorizationCode() of an expired code error = %v, want sql.ErrNoRows", err)
+	}
+	s.now = time.Now
+
+	clientID := uuid.NullUUID{UUID: client.ID, Valid: true}
+	s.CreateRefreshToken(ctx, CreateRefreshTokenParams{
+		UserID:               user.ID,
+		TokenHash:            "token",
+		FamilyID:             arg.FamilyID,
+		AccessTokenID:        uuid.NullUUID{UUID: uuid.New(), Valid: true},
+		AccessTokenExpiresAt: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+		ClientID:             clientID,
+		Scopes:               arg.Scopes,
+	})
+	if revoked, _ := s.RevokeAccessTokensForClient(ctx, clientID); len(revoked) != 1 {
+		t.Errorf("RevokeAccessTokensForClient() = %+v, want the token issued to the client", revoked)
+	}
+
+	if _, err := s.DeleteOauthClientForOwner(ctx, DeleteOauthClientForOwnerParams{ID: client.ID, OwnerID: user.ID}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("DeleteOauthClientForOwner() by another user error = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := s.DeleteOauthClientForOwner(ctx, DeleteOauthClientForOwnerParams{ID: client.ID, OwnerID: owner.ID}); err != nil {
+		t.Fatalf("DeleteOauthClientForOwner() error = %v", err)
+	}
+	// the client's codes and refresh tokens go with it
+	if _, err := s.GetOauthAuthorizationCode(ctx, "code"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetOauthAuthorizationCode() after deleting the client error = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := s.GetRefreshToken(ctx, "token"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetRefreshToken() after deleting the client error = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestMemoryStoreUserIdentities(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+
+	user, _ := s.CreateUser(ctx, CreateUserParams{Email: "a@example.com", HashedPassword: "hash"})
+	arg := CreateUserIdentityParams{Provider: "example", Subject: "1234", UserID: user.ID, Email: user.Email}
+	if _, err := s.CreateUserIdentity(ctx, arg); err != nil {
+		t.Fatalf("CreateUserIdentity() error = %v", err)
+	}
+	var pqErr *pq.Error
+	if _, err := s.CreateUserIdentity(ctx, arg); !errors.As(err, &pqErr) || pqErr.Code != "23505" {
+		t.Errorf("CreateUserIdentity() twice error = %v, want SQLSTATE 23505", err)
+	}
+
+	key := GetUserIdentityParams{Provider: "example", Subject: "1234"}
+	identity, err := s.GetUserIdentity(ctx, key)
+	if err != nil || identity.UserID != user.ID {
+		t.Errorf("GetUserIdentity() = %+v, %v, want an identity of %v", identity, err, user.ID)
+	}
+	if _, err := s.GetUserIdentity(ctx, GetUserIdentityParams{Provider: "other", Subject: "1234"}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetUserIdentity() at another provider error = %v, want sql.ErrNoRows", err)
+	}
+
+	// identities go with their user
+	s.DeleteAllUsers(ctx)
+	if _, err := s.GetUserIdentity(ctx, key); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetUserIdentity() after deleting the user error = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestMemoryStoreChirpsKeysetPagination(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+
+	clock := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return clock }
+
+	user, _ := s.CreateUser(ctx, CreateUserParams{Email: "a@example.com", HashedPassword: "hash"})
+	for _, body := range []string{"one", "two", "three", "four", "five"} {
+		clock = clock.Add(time.Minute)
+		s.CreateChirp(ctx, CreateChirpParams{UserID: user.ID, Body: body})
+	}
+
+	for _, order := range []string{"ASC", "DESC"} {
+		t.Run(order, func(t *testing.T) {
+			var seen []string
+			var cursorCreatedAt sql.NullTime
+			var cursorID uuid.NullUUID
+			for {
+				page, err := s.GetAllChirps(ctx, GetAllChirpsParams{
+					CursorCreatedAt: cursorCreatedAt,
+					Sort:            order,
+					CursorID:        cursorID,
+					RowLimit:        2,
+				})
+				if err != nil {
+					t.Fatalf("GetAllChirps() error = %v", err)
+				}
+				if len(page) == 0 {
+					break
+				}
+				for _, chirp := range page {
+					seen = append(seen, chirp.Body)
+				}
+				last := page[len(page)-1]
+				cursorCreatedAt = sql.NullTime{Time: last.CreatedAt, Valid: true}
+				cursorID = uuid.NullUUID{UUID: last.ID, Valid: true}
+			}
+
+			want := []string{"one", "two", "three", "four", "five"}
+			if order == "DESC" {
+				want = []string{"five", "four", "three", "two", "one"}
+			}
+			if strings.Join(seen, ",") != strings.Join(want, ",") {
+				t.Errorf("pages = %v, want %v", seen, want)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreChirpLikes(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+
+	clock := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return clock }
+
+	author, _ := s.CreateUser(ctx, CreateUserParams{Email: "a@example.com", HashedPassword: "hash"})
+	fan, _ := s.CreateUser(ctx, CreateUserParams{Email: "b@example.com", HashedPassword: "hash"})
+	chirps := make(map[string]Chirp)
+	for _, body := range []string{"one", "two", "three"} {
+		clock = clock.Add(time.Minute)
+		chirps[body], _ = s.CreateChirp(ctx, CreateChirpParams{UserID: author.ID, Body: body})
+	}
+
+	// liking twice counts once
+	for _, like := range []LikeChirpParams{
+		{ChirpID: chirps["one"].ID, UserID: fan.ID},
+		{ChirpID: chirps["one"].ID, UserID: fan.ID},
+		{ChirpID: chirps["one"].ID, UserID: author.ID},
+		{ChirpID: chirps["three"].ID, UserID: fan.ID},
+	} {
+		if err := s.LikeChirp(ctx, like); err != nil {
+			t.Fatalf("LikeChirp() error = %v", err)
+		}
+	}
+	if chirp, _ := s.GetChirpById(ctx, chirps["one"].ID); chirp.LikeCount != 2 {
+		t.Errorf("LikeCount = %d, want 2", chirp.LikeCount)
+	}
+	if err := s.LikeChirp(ctx, LikeChirpParams{ChirpID: uuid.New(), UserID: fan.ID}); err == nil {
+		t.Errorf("LikeChirp() of an unknown chirp succeeded")
+	}
+
+	liked, _ := s.ListLikedChirpIDs(ctx, ListLikedChirpIDsParams{
+		UserID:   author.ID,
+		ChirpIds: []uuid.UUID{chirps["one"].ID, chirps["two"].ID, chirps["three"].ID},
+	})
+	if len(liked) != 1 || liked[0] != chirps["one"].ID {
+		t.Errorf("ListLikedChirpIDs() = %v, want only chirp one", liked)
+	}
+
+	// most liked first, then newest first, one chirp per page
+	var seen []string
+	var cursor Chirp
+	for {
+		params := GetAllChirpsParams{Sort: "LIKES", RowLimit: 1}
+		if cursor.ID != uuid.Nil {
+			params.CursorCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+			params.CursorID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+			params.CursorLikeCount = sql.NullInt32{Int32: cursor.LikeCount, Valid: true}
+		}
+		page, _ := s.GetAllChirps(ctx, params)
+		if len(page) == 0 {
+			break
+		}
+		seen = append(seen, page[0].Body)
+		cursor = page[0]
+	}
+	if got := strings.Join(seen, ","); got != "one,three,two" {
+		t.Errorf("pages sorted by likes = %v, want one,three,two", got)
+	}
+
+	// unliking twice is a no-op too, and a user's likes go with them
+	s.UnlikeChirp(ctx, UnlikeChirpParams{ChirpID: chirps["one"].ID, UserID: author.ID})
+	s.UnlikeChirp(ctx, UnlikeChirpParams{ChirpID: chirps["one"].ID, UserID: author.ID})
+	s.deleteUser(fan.ID)
+	for _, body := range []string{"one", "three"} {
+		if chirp, _ := s.GetChirpById(ctx, chirps[body].ID); chirp.LikeCount != 0 {
+			t.Errorf("LikeCount of %s = %d, want 0", body, chirp.LikeCount)
+		}
+	}
+}
+
+func TestMemoryStoreChirpReplies(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+
+	clock := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return clock }
+
+	user, _ := s.CreateUser(ctx, CreateUserParams{Email: "a@example.com", HashedPassword: "hash"})
+	chirps := make(map[string]Chirp)
+	for _, c := range []struct{ body, replyTo string }{
+		{"root", ""},
+		{"reply", "root"},
+		{"nested", "reply"},
+		{"second", "root"},
+	} {
+		clock = clock.Add(time.Minute)
+		params := CreateChirpParams{UserID: user.ID, Body: c.body}
+		if c.replyTo != "" {
+			params.ReplyToID = uuid.NullUUID{UUID: chirps[c.replyTo].ID, Valid: true}
+		}
+		chirps[c.body], _ = s.CreateChirp(ctx, params)
+	}
+	if _, err := s.CreateChirp(ctx, CreateChirpParams{UserID: user.ID, Body: "orphan", ReplyToID: uuid.NullUUID{UUID: uuid.New(), Valid: true}}); err == nil {
+		t.Errorf("CreateChirp() replying to an unknown chirp succeeded")
+	}
+	if root, _ := s.GetChirpById(ctx, chirps["root"].ID); root.ReplyCount != 2 {
+		t.Errorf("ReplyCount = %d, want 2", root.ReplyCount)
+	}
+
+	ancestors, _ := s.GetChirpAncestors(ctx, chirps["nested"].ID)
+	if len(ancestors) != 2 || ancestors[0].Body != "root" || ancestors[1].Body != "reply" {
+		t.Errorf("GetChirpAncestors() = %v, want root then reply", ancestors)
+	}
+
+	// oldest first, one reply per page
+	var seen []string
+	var cursor Chirp
+	for {
+		params := GetChirpDescendantsParams{ID: chirps["root"].ID, RowLimit: 1}
+		if cursor.ID != uuid.Nil {
+			params.CursorCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+			params.CursorID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+		}
+		page, _ := s.GetChirpDescendants(ctx, params)
+		if len(page) == 0 {
+			break
+		}
+		seen = append(seen, page[0].Body)
+		cursor = page[0]
+	}
+	if got := strings.Join(seen, ","); got != "reply,nested,second" {
+		t.Errorf("descendants = %v, want reply,nested,second", got)
+	}
+
+	// chirps with replies are only marked deleted
+	if _, err := s.DeleteChirpById(ctx, DeleteChirpByIdParams{ID: chirps["reply"].ID, UserID: user.ID}); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("DeleteChirpById() of a chirp with replies error = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := s.MarkChirpDeleted(ctx, MarkChirpDeletedParams{ID: chirps["reply"].ID, UserID: user.ID}); err != nil {
+		t.Fatalf("MarkChirpDeleted() error = %v", err)
+	}
+	if _, err := s.GetChirpById(ctx, chirps["reply"].ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetChirpById() of a deleted chirp error = %v, want sql.ErrNoRows", err)
+	}
+	ancestors, _ = s.GetChirpAncestors(ctx, chirps["nested"].ID)
+	if len(ancestors) != 2 || ancestors[1].Body != "" || !ancestors[1].DeletedAt.Valid {
+		t.Errorf("GetChirpAncestors() = %v, want the deleted chirp without its body", ancestors)
+	}
+	if root, _ := s.GetChirpById(ctx, chirps["root"].ID); root.ReplyCount != 1 {
+		t.Errorf("ReplyCount after deleting a reply = %d, want 1", root.ReplyCount)
+	}
+	if _, err := s.CreateChirp(ctx, CreateChirpParams{UserID: user.ID, Body: "reply"}); err != nil {
+		t.Errorf("CreateChirp() reusing the body of a deleted chirp error = %v", err)
+	}
+
+	// replies outlive the chirp they reply to
+	if _, err := s.DeleteChirpById(ctx, DeleteChirpByIdParams{ID: chirps["second"].ID, UserID: user.ID}); err != nil {
+		t.Fatalf("DeleteChirpById() error = %v", err)
+	}
+	if root, _ := s.GetChirpById(ctx, chirps["root"].ID); root.ReplyCount != 0 {
+		t.Errorf("ReplyCount after deleting every reply = %d, want 0", root.ReplyCount)
+	}
+	s.deleteChirp(chirps["root"].ID)
+	if reply, ok := s.chirps[chirps["reply"].ID]; !ok || reply.ReplyToID.Valid {
+		t.Errorf("reply after deleting root = %+v, %v, want it kept without a parent", reply, ok)
+	}
+}
+
+func TestMemoryStoreSearchChirps(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+
+	user, _ := s.CreateUser(ctx, CreateUserParams{Email: "a@example.com", HashedPassword: "hash"})
+	other, _ := s.CreateUser(ctx, CreateUserParams{Email: "b@example.com", HashedPassword: "hash"})
+	s.CreateChirp(ctx, CreateChirpParams{UserID: user.ID, Body: "The big red dog barks"})
+	s.CreateChirp(ctx, CreateChirpParams{UserID: user.ID, Body: "A red big dog, red again"})
+	s.CreateChirp(ctx, CreateChirpParams{UserID: other.ID, Body: "Dogmatic red herring"})
+
+	tests := []struct {
+		name     string
+		query    string
+		authorID uuid.NullUUID
+		want     int
+	}{
+		{name: "Single lexeme", query: "red", want: 3},
+		{name: "Phrase", query: "(big <-> red <-> dog)", want: 1},
+		{name: "Prefix", query: "dog:*", want: 3},
+		{name: "And", query: "red & barks", want: 1},
+		{name: "Author filter", query: "red", authorID: uuid.NullUUID{UUID: other.ID, Valid: true}, want: 1},
+		{name: "No match", query: "cat", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.SearchChirps(ctx, SearchChirpsParams{Query: tt.query, AuthorID: tt.authorID, RowLimit: 10})
+			if err != nil {
+				t.Fatalf("SearchChirps() error = %v", err)
+			}
+			if len(got) != tt.want {
+				t.Errorf("SearchChirps() returned %d chirps, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+func (s *MemoryStore) UpsertUserTotp(ctx context.Context, arg UpsertUserTotpParams) (UserTotp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[arg.UserID]; !ok {
+		return UserTotp{}, foreignKeyViolation("user_totp", "user_totp_user_id_fkey")
+	}
+	if totp, ok := s.totps[arg.UserID]; ok && totp.ConfirmedAt.Valid {
+		return UserTotp{}, sql.ErrNoRows
+	}
+
+	totp := UserTotp{
+		UserID:    arg.UserID,
+		Secret:    arg.Secret,
+		CreatedAt: s.timestamp(),
+	}
+	s.totps[arg.UserID] = totp
+	return totp, nil
+}
+
+func (s *MemoryStore) GetUserTotp(ctx context.Context, userID uuid.UUID) (UserTotp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	totp, ok := s.totps[userID]
+	if !ok {
+		return UserTotp{}, sql.ErrNoRows
+	}
+	return totp, nil
+}
+
+func (s *MemoryStore) ConfirmUserTotp(ctx context.Context, arg ConfirmUserTotpParams) (UserTotp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	totp, ok := s.totps[arg.UserID]
+	if !ok || totp.ConfirmedAt.Valid {
+		return UserTotp{}, sql.ErrNoRows
+	}
+	totp.ConfirmedAt = sql.NullTime{Time: s.timestamp(), Valid: true}
+	totp.LastUsedStep = arg.LastUsedStep
+	s.totps[arg.UserID] = totp
+	return totp, nil
+}
+
+func (s *MemoryStore) UseTotpStep(ctx context.Context, arg UseTotpStepParams) (UserTotp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	totp, ok := s.totps[arg.UserID]
+	if !ok || !totp.ConfirmedAt.Valid || totp.LastUsedStep >= arg.LastUsedStep {
+		return UserTotp{}, sql.ErrNoRows
+	}
+	totp.LastUsedStep = arg.LastUsedStep
+	s.totps[arg.UserID] = totp
+	return totp, nil
+}
+
+func (s *MemoryStore) CreateTotpRecoveryCodes(ctx context.Context, arg CreateTotpRecoveryCodesParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[arg.UserID]; !ok {
+		return foreignKeyViolation("totp_recovery_codes", "totp_recovery_codes_user_id_fkey")
+	}
+	seen := make(map[string]bool, len(arg.CodeHashes))
+	for _, codeHash := range arg.CodeHashes {
+		if _, ok := s.recoveryCodes[codeHash]; ok || seen[codeHash] {
+			return uniqueViolation("totp_recovery_codes", "totp_recovery_codes_pkey")
+		}
+		seen[codeHash] = true
+	}
+
+	now := s.timestamp()
+	for _, codeHash := range arg.CodeHashes {
+		s.recoveryCodes[codeHash] = TotpRecoveryCode{
+			CodeHash:  codeHash,
+			UserID:    arg.UserID,
+			CreatedAt: now,
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) DeleteTotpRecoveryCodesForUser(ctx context.Context, userID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for codeHash, recoveryCode := range s.recoveryCodes {
+		if recoveryCode.UserID == userID {
+			delete(s.recoveryCodes, codeHash)
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) UseTotpRecoveryCode(ctx context.Context, arg UseTotpRecoveryCodeParams) (TotpRecoveryCode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recoveryCode, ok := s.recoveryCodes[arg.CodeHash]
+	if !ok || recoveryCode.UserID != arg.UserID || recoveryCode.UsedAt.Valid {
+		return TotpRecoveryCode{}, sql.ErrNoRows
+	}
+	recoveryCode.UsedAt = sql.NullTime{Time: s.timestamp(), Valid: true}
+	s.recoveryCodes[arg.CodeHash] = recoveryCode
+	return recoveryCode, nil
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+// userIdentityKey is the primary key of user_identities.
+type userIdentityKey struct {
+	provider string
+	subject  string
+}
+
+func (s *MemoryStore) CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) (UserIdentity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[arg.UserID]; !ok {
+		return UserIdentity{}, foreignKeyViolation("user_identities", "user_identities_user_id_fkey")
+	}
+	key := userIdentityKey{provider: arg.Provider, subject: arg.Subject}
+	if _, ok := s.identities[key]; ok {
+		return UserIdentity{}, uniqueViolation("user_identities", "user_identities_pkey")
+	}
+
+	now := s.timestamp()
+	identity := UserIdentity{
+		Provider:    arg.Provider,
+		Subject:     arg.Subject,
+		UserID:      arg.UserID,
+		Email:       arg.Email,
+		CreatedAt:   now,
+		LastLoginAt: now,
+	}
+	s.identities[key] = identity
+	return identity, nil
+}
+
+func (s *MemoryStore) GetUserIdentity(ctx context.Context, arg GetUserIdentityParams) (UserIdentity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	identity, ok := s.identities[userIdentityKey{provider: arg.Provider, subject: arg.Subject}]
+	if !ok {
+		return UserIdentity{}, sql.ErrNoRows
+	}
+	return identity, nil
+}
+
+func (s *MemoryStore) TouchUserIdentity(ctx context.Context, arg TouchUserIdentityParams) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := userIdentityKey{provider: arg.Provider, subject: arg.Subject}
+	identity, ok := s.identities[key]
+	if !ok {
+		return nil
+	}
+	identity.LastLoginAt = s.timestamp()
+	s.identities[key] = identity
+	return nil
+}
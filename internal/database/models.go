@@ -11,21 +11,124 @@ import (
 	"github.com/google/uuid"
 )
 
-type Chirp struct {
-	ID        uuid.UUID `json:"id"`
+type ApiKey struct {
+	ID         uuid.UUID    `json:"id"`
+	UserID     uuid.UUID    `json:"user_id"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	KeyHash    string       `json:"key_hash"`
+	Scopes     []string     `json:"scopes"`
+	CreatedAt  time.Time    `json:"created_at"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+	RevokedAt  sql.NullTime `json:"revoked_at"`
+}
+
+type BannedWord struct {
+	Word      string    `json:"word"`
+	Action    string    `json:"action"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
+	CreatedBy string    `json:"created_by"`
+}
+
+type BannedWordChange struct {
+	ID        uuid.UUID `json:"id"`
+	Word      string    `json:"word"`
+	Change    string    `json:"change"`
+	Action    string    `json:"action"`
+	ChangedBy string    `json:"changed_by"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+type Chirp struct {
+	ID         uuid.UUID     `json:"id"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+	Body       string        `json:"body"`
+	UserID     uuid.UUID     `json:"user_id"`
+	BodyTsv    string        `json:"-"`
+	LikeCount  int32         `json:"like_count"`
+	ReplyToID  uuid.NullUUID `json:"reply_to_id"`
+	DeletedAt  sql.NullTime  `json:"-"`
+	ReplyCount int32         `json:"reply_count"`
+}
+
+type ChirpLike struct {
+	ChirpID   uuid.UUID `json:"chirp_id"`
 	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
-type RefreshToken struct {
-	Token     string       `json:"token"`
-	CreatedAt time.Time    `json:"created_at"`
-	UpdatedAt time.Time    `json:"updated_at"`
+type ChirpModerationDecision struct {
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	ChirpID     uuid.UUID `json:"chirp_id"`
+	Rule        string    `json:"rule"`
+	Action      string    `json:"action"`
+	MatchedText string    `json:"matched_text"`
+}
+
+type OauthAuthorizationCode struct {
+	CodeHash      string       `json:"code_hash"`
+	ClientID      uuid.UUID    `json:"client_id"`
+	UserID        uuid.UUID    `json:"user_id"`
+	RedirectUri   string       `json:"redirect_uri"`
+	Scopes        []string     `json:"scopes"`
+	CodeChallenge string       `json:"code_challenge"`
+	FamilyID      uuid.UUID    `json:"family_id"`
+	CreatedAt     time.Time    `json:"created_at"`
+	ExpiresAt     time.Time    `json:"expires_at"`
+	UsedAt        sql.NullTime `json:"used_at"`
+}
+
+type OauthClient struct {
+	ID           uuid.UUID      `json:"id"`
+	OwnerID      uuid.UUID      `json:"owner_id"`
+	Name         string         `json:"name"`
+	RedirectUris []string       `json:"redirect_uris"`
+	SecretHash   sql.NullString `json:"secret_hash"`
+	CreatedAt    time.Time      `json:"created_at"`
+}
+
+type PasswordResetToken struct {
+	TokenHash string       `json:"token_hash"`
 	UserID    uuid.UUID    `json:"user_id"`
+	CreatedAt time.Time    `json:"created_at"`
 	ExpiresAt time.Time    `json:"expires_at"`
-	RevokedAt sql.NullTime `json:"revoked_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+}
+
+type RefreshToken struct {
+	TokenHash            string         `json:"token_hash"`
+	CreatedAt            time.Time      `json:"created_at"`
+	UpdatedAt            time.Time      `json:"updated_at"`
+	UserID               uuid.UUID      `json:"user_id"`
+	ExpiresAt            time.Time      `json:"expires_at"`
+	RevokedAt            sql.NullTime   `json:"revoked_at"`
+	FamilyID             uuid.UUID      `json:"family_id"`
+	ReplacedBy           sql.NullString `json:"replaced_by"`
+	ID                   uuid.UUID      `json:"id"`
+	LastUsedAt           sql.NullTime   `json:"last_used_at"`
+	UserAgent            string         `json:"user_agent"`
+	IpAddress            string         `json:"ip_address"`
+	AccessTokenID        uuid.NullUUID  `json:"access_token_id"`
+	AccessTokenExpiresAt sql.NullTime   `json:"access_token_expires_at"`
+	ClientID             uuid.NullUUID  `json:"client_id"`
+	Scopes               []string       `json:"scopes"`
+}
+
+type RevokedAccessToken struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
+type TotpRecoveryCode struct {
+	CodeHash  string       `json:"code_hash"`
+	UserID    uuid.UUID    `json:"user_id"`
+	CreatedAt time.Time    `json:"created_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
 }
 
 type User struct {
@@ -35,4 +138,25 @@ type User struct {
 	Email          string       `json:"email"`
 	HashedPassword string       `json:"hashed_password"`
 	IsChirpyRed    sql.NullBool `json:"is_chirpy_red"`
+	Role           string       `json:"role"`
+	BannedAt       sql.NullTime `json:"banned_at"`
+	VerifiedAt     sql.NullTime `json:"verified_at"`
+	DeletedAt      sql.NullTime `json:"deleted_at"`
+}
+
+type UserIdentity struct {
+	Provider    string    `json:"provider"`
+	Subject     string    `json:"subject"`
+	UserID      uuid.UUID `json:"user_id"`
+	Email       string    `json:"email"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastLoginAt time.Time `json:"last_login_at"`
+}
+
+type UserTotp struct {
+	UserID       uuid.UUID    `json:"user_id"`
+	Secret       string       `json:"secret"`
+	CreatedAt    time.Time    `json:"created_at"`
+	ConfirmedAt  sql.NullTime `json:"confirmed_at"`
+	LastUsedStep int64        `json:"last_used_step"`
 }
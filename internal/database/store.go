@@ -0,0 +1,46 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Store is the persistence layer used by the handlers. It is satisfied by
+// PostgresStore as well as by the in-memory MemoryStore, so handlers can be
+// exercised without a live Postgres.
+type Store interface {
+	Querier
+	// InTx runs fn in a transaction: the writes made through the Querier fn
+	// is given are only kept if fn returns nil.
+	InTx(ctx context.Context, fn func(Querier) error) error
+}
+
+var (
+	_ Store = (*PostgresStore)(nil)
+	_ Store = (*MemoryStore)(nil)
+)
+
+// PostgresStore is the Store backed by Postgres through the sqlc generated
+// Queries.
+type PostgresStore struct {
+	*Queries
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{Queries: New(db), db: db}
+}
+
+func (s *PostgresStore) InTx(ctx context.Context, fn func(Querier) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// a no-op once the transaction is committed
+	defer tx.Rollback()
+
+	if err := fn(s.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/kevinjimenez96/chirpy/internal/database"
+	"github.com/kevinjimenez96/chirpy/internal/types"
+)
+
+// DeleteAccountHandler soft-deletes the caller's account: their chirps are
+// hidden and they are signed out everywhere at once, but the account can be
+// restored until the grace period ends and it is purged.
+func DeleteAccountHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	_, err := cfg.DbQueries.SoftDeleteUser(r.Context(), principal.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "User not found", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error deleting account: %s", err), err)
+		return
+	}
+
+	err = revokeAllSessions(r, cfg, principal.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error revoking sessions: %s", err), err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// RestoreAccountHandler undoes a deletion within the grace period. A deleted
+// account has no valid tokens left, so the caller proves who they are with
+// their email and password instead, throttled like logging in.
+func RestoreAccountHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	decoder := json.NewDecoder(r.Body)
+	loginUserReq := types.LoginUserReq{}
+	err := decoder.Decode(&loginUserReq)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Error decoding restore account request: %s", err), err)
+		return
+	}
+
+	user, ok := checkPassword(w, r, cfg, loginUserReq)
+	if !ok {
+		return
+	}
+	// a live account answers like a wrong password and keeps the attempt
+	// counted, or the right password would stand out
+	if !user.DeletedAt.Valid {
+		respondWithError(w, http.StatusUnauthorized, incorrectCredentialsMessage, nil)
+		return
+	}
+	acceptAttempt(r, cfg, loginUserReq.Email)
+
+	user, err = cfg.DbQueries.RestoreUser(r.Context(), database.RestoreUserParams{
+		ID:           user.ID,
+		DeletedAfter: sql.NullTime{Time: time.Now().UTC().Add(-cfg.DeletionGracePeriod), Valid: true},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusGone, "Error: the account can no longer be restored", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error restoring account: %s", err), err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, types.LoginUserRes{
+		ID:          user.ID,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+		Email:       user.Email,
+		IsChirpyRed: user.IsChirpyRed.Bool,
+		Role:        user.Role,
+		IsVerified:  user.VerifiedAt.Valid,
+	})
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kevinjimenez96/chirpy/internal/database"
+	"github.com/kevinjimenez96/chirpy/internal/types"
+)
+
+func TestDeleteAndRestoreAccount(t *testing.T) {
+	server, cfg := testServer(t)
+	createTestUser(t, cfg, "a@example.com", "password1")
+	session := login(t, server, "a@example.com", "password1")
+	credentials := types.LoginUserReq{Email: "a@example.com", Password: "password1"}
+
+	chirp := database.Chirp{}
+	if status := doJSON(t, http.MethodPost, server.URL+"/api/chirps", session.Token, types.AddChirpReq{Chirp: types.Chirp{Body: "goodbye world"}}, &chirp); status != http.StatusCreated {
+		t.Fatalf("POST /api/chirps = %d, want 201", status)
+	}
+	// chirps of a deleted account are hidden from every way of reading them
+	checkChirpVisible := func(want bool) {
+		t.Helper()
+		page := types.ChirpsPage{}
+		doJSON(t, http.MethodGet, server.URL+"/api/chirps", "", nil, &page)
+		if len(page.Chirps) == 1 != want {
+			t.Errorf("GET /api/chirps = %+v, want the chirp listed: %v", page.Chirps, want)
+		}
+		found := []database.SearchChirpsRow{}
+		doJSON(t, http.MethodGet, server.URL+"/api/chirps/search?q=goodbye", "", nil, &found)
+		if len(found) == 1 != want {
+			t.Errorf("GET /api/chirps/search = %+v, want the chirp found: %v", found, want)
+		}
+		status := doJSON(t, http.MethodGet, server.URL+"/api/chirps/"+chirp.ID.String(), "", nil, nil)
+		if status == http.StatusOK != want {
+			t.Errorf("GET /api/chirps/{id} = %d, want the chirp returned: %v", status, want)
+		}
+	}
+	checkChirpVisible(true)
+
+	if status := doJSON(t, http.MethodDelete, server.URL+"/api/users/me", session.Token, nil, nil); status != http.StatusNoContent {
+		t.Fatalf("DELETE /api/users/me = %d, want 204", status)
+	}
+	checkChirpVisible(false)
+	if status := doJSON(t, http.MethodPost, server.URL+"/api/refresh", session.RefreshToken, nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("POST /api/refresh after deleting the account = %d, want 401", status)
+	}
+	if status := doJSON(t, http.MethodPost, server.URL+"/api/login", "", credentials, nil); status != http.StatusForbidden {
+		t.Errorf("POST /api/login to a deleted account = %d, want 403", status)
+	}
+
+	if status := doJSON(t, http.MethodPost, server.URL+"/api/users/me/restore", "", credentials, nil); status != http.StatusOK {
+		t.Fatalf("POST /api/users/me/restore = %d, want 200", status)
+	}
+	checkChirpVisible(true)
+	session = login(t, server, "a@example.com", "password1")
+
+	// a negative grace period puts every deletion past it
+	doJSON(t, http.MethodDelete, server.URL+"/api/users/me", session.Token, nil, nil)
+	cfg.DeletionGracePeriod = -time.Minute
+	if status := doJSON(t, http.MethodPost, server.URL+"/api/users/me/restore", "", credentials, nil); status != http.StatusGone {
+		t.Errorf("POST /api/users/me/restore after the grace period = %d, want 410", status)
+	}
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/kevinjimenez96/chirpy/internal/auth"
+	"github.com/kevinjimenez96/chirpy/internal/database"
+	"github.com/kevinjimenez96/chirpy/internal/types"
+)
+
+// maxAPIKeyNameLength caps the name users give their API keys.
+const maxAPIKeyNameLength = 100
+
+func apiKeyRes(apiKey database.ApiKey) types.APIKeyRes {
+	res := types.APIKeyRes{
+		ID:        apiKey.ID,
+		Name:      apiKey.Name,
+		Prefix:    apiKey.Prefix,
+		Scopes:    apiKey.Scopes,
+		CreatedAt: apiKey.CreatedAt,
+	}
+	if apiKey.LastUsedAt.Valid {
+		res.LastUsedAt = &apiKey.LastUsedAt.Time
+	}
+	return res
+}
+
+func CreateAPIKeyHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	createAPIKeyReq := types.CreateAPIKeyReq{}
+	err := decoder.Decode(&createAPIKeyReq)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Error decoding create API key request: %s", err), err)
+		return
+	}
+
+	name := strings.TrimSpace(createAPIKeyReq.Name)
+	if name == "" || len(name) > maxAPIKeyNameLength {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Error: name must be 1 to %d characters long", maxAPIKeyNameLength), nil)
+		return
+	}
+
+	if len(createAPIKeyReq.Scopes) == 0 {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Error: scopes must name at least one of %s", strings.Join(auth.Scopes, ", ")), nil)
+		return
+	}
+	for _, scope := range createAPIKeyReq.Scopes {
+		if !auth.ValidScope(scope) {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Error: unknown scope %q, use %s", scope, strings.Join(auth.Scopes, ", ")), nil)
+			return
+		}
+	}
+	// a key can't hand out more than it holds
+	for _, scope := range createAPIKeyReq.Scopes {
+		if !principal.HasScope(scope) {
+			respondWithError(w, http.StatusForbidden, fmt.Sprintf("Error: scope %q is not held by this API key", scope), nil)
+			return
+		}
+	}
+	scopes := slices.Clone(createAPIKeyReq.Scopes)
+	slices.Sort(scopes)
+	scopes = slices.Compact(scopes)
+
+	key, prefix, err := auth.MakeAPIKey()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error generating API key: %s", err), err)
+		return
+	}
+
+	apiKey, err := cfg.DbQueries.CreateApiKey(r.Context(), database.CreateApiKeyParams{
+		UserID:  principal.UserID,
+		Name:    name,
+		Prefix:  prefix,
+		KeyHash: auth.HashAPIKey(key),
+		Scopes:  scopes,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error saving API key: %s", err), err)
+		return
+	}
+
+	res := apiKeyRes(apiKey)
+	res.Key = key
+	respondWithJSON(w, http.StatusCreated, res)
+}
+
+func ListAPIKeysHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	apiKeys, err := cfg.DbQueries.ListActiveApiKeysByUser(r.Context(), principal.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error listing API keys: %s", err), err)
+		return
+	}
+
+	res := make([]types.APIKeyRes, 0, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		res = append(res, apiKeyRes(apiKey))
+	}
+
+	respondWithJSON(w, http.StatusOK, res)
+}
+
+func RevokeAPIKeyHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid API key id: %s", err), err)
+		return
+	}
+
+	_, err = cfg.DbQueries.RevokeApiKeyForUser(r.Context(), database.RevokeApiKeyForUserParams{
+		ID:     id,
+		UserID: principal.UserID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "API key not found", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error revoking API key: %s", err), err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/kevinjimenez96/chirpy/internal/auth"
+	"github.com/kevinjimenez96/chirpy/internal/types"
+)
+
+func TestAPIKeys(t *testing.T) {
+	server, cfg := testServer(t)
+	user, _ := createTestUser(t, cfg, "a@example.com", "password1")
+	session := login(t, server, "a@example.com", "password1")
+
+	createKey := func(token string) types.APIKeyRes {
+		t.Helper()
+		res := types.APIKeyRes{}
+		req := types.CreateAPIKeyReq{Name: "script", Scopes: []string{auth.ScopeProfileRead, auth.ScopeChirpsWrite}}
+		if status := doJSON(t, http.MethodPost, server.URL+"/api/users/me/api-keys", token, req, &res); status != http.StatusCreated || res.Key == "" {
+			t.Fatalf("POST /api/users/me/api-keys = %d %+v, want 201 and the key", status, res)
+		}
+		return res
+	}
+	getMe := func(key string) int {
+		t.Helper()
+		return doAuthorized(t, http.MethodGet, server.URL+"/api/users/me", "ApiKey "+key, nil, nil)
+	}
+	apiKey := createKey(session.Token)
+
+	me := types.LoginUserRes{}
+	if status := doAuthorized(t, http.MethodGet, server.URL+"/api/users/me", "ApiKey "+apiKey.Key, nil, &me); status != http.StatusOK || me.ID != user.ID {
+		t.Fatalf("GET /api/users/me with an API key = %d %+v, want 200 and its user", status, me)
+	}
+	if status := doAuthorized(t, http.MethodPost, server.URL+"/api/chirps", "ApiKey "+apiKey.Key, types.AddChirpReq{Chirp: types.Chirp{Body: "from a script"}}, nil); status != http.StatusCreated {
+		t.Errorf("POST /api/chirps with an API key = %d, want 201", status)
+	}
+
+	// keys only reach the routes their scopes cover
+	if status := doAuthorized(t, http.MethodPut, server.URL+"/api/users", "ApiKey "+apiKey.Key, types.UpdateUserReq{CurrentPassword: "password1"}, nil); status != http.StatusForbidden {
+		t.Errorf("PUT /api/users with a key without %q = %d, want 403", auth.ScopeAccount, status)
+	}
+	if status := doAuthorized(t, http.MethodPost, server.URL+"/api/users/me/api-keys", "ApiKey "+apiKey.Key, types.CreateAPIKeyReq{Name: "more", Scopes: []string{auth.ScopeAccount}}, nil); status != http.StatusForbidden {
+		t.Errorf("POST /api/users/me/api-keys with a key without %q = %d, want 403", auth.ScopeAccount, status)
+	}
+
+	for _, key := range []string{"not-a-key", "chirpy_" + apiKey.Prefix, apiKey.Key + "x"} {
+		if status := getMe(key); status != http.StatusUnauthorized {
+			t.Errorf("GET /api/users/me with API key %q = %d, want 401", key, status)
+		}
+	}
+
+	if status := doJSON(t, http.MethodDelete, server.URL+"/api/users/me/api-keys/"+apiKey.ID.String(), session.Token, nil, nil); status != http.StatusNoContent {
+		t.Fatalf("DELETE /api/users/me/api-keys/{id} = %d, want 204", status)
+	}
+	if status := getMe(apiKey.Key); status != http.StatusUnauthorized {
+		t.Errorf("GET /api/users/me with a revoked API key = %d, want 401", status)
+	}
+
+	// keys stop working while their user is banned or deleted
+	apiKey = createKey(session.Token)
+	ban := server.URL + "/admin/users/" + user.ID.String() + "/ban"
+	if status := doAuthorized(t, http.MethodPost, ban, "ApiKey "+testAdminKey, nil, nil); status != http.StatusNoContent {
+		t.Fatalf("POST /admin/users/{id}/ban = %d, want 204", status)
+	}
+	if status := getMe(apiKey.Key); status != http.StatusUnauthorized {
+		t.Errorf("GET /api/users/me with the API key of a banned user = %d, want 401", status)
+	}
+	if status := doAuthorized(t, http.MethodDelete, ban, "ApiKey "+testAdminKey, nil, nil); status != http.StatusNoContent {
+		t.Fatalf("DELETE /admin/users/{id}/ban = %d, want 204", status)
+	}
+	if status := getMe(apiKey.Key); status != http.StatusOK {
+		t.Errorf("GET /api/users/me with the API key of an unbanned user = %d, want 200", status)
+	}
+
+	session = login(t, server, "a@example.com", "password1")
+	if status := doJSON(t, http.MethodDelete, server.URL+"/api/users/me", session.Token, nil, nil); status != http.StatusNoContent {
+		t.Fatalf("DELETE /api/users/me = %d, want 204", status)
+	}
+	if status := getMe(apiKey.Key); status != http.StatusUnauthorized {
+		t.Errorf("GET /api/users/me with the API key of a deleted user = %d, want 401", status)
+	}
+}
@@ -1,36 +1,75 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"strings"
 
 	"github.com/google/uuid"
-	"github.com/kevinjimenez96/chirpy/internal/auth"
 	"github.com/kevinjimenez96/chirpy/internal/database"
 	"github.com/kevinjimenez96/chirpy/internal/types"
 )
 
+const (
+	defaultChirpsPageSize = 20
+	maxChirpsPageSize     = 100
+)
+
 func GetAllChirps(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
 	authorId := r.URL.Query().Get("author_id")
 	sortParam := r.URL.Query().Get("sort")
 
 	sort := "ASC"
-	if sortParam == "desc" {
+	switch sortParam {
+	case "desc":
 		sort = "DESC"
+	case "likes":
+		sort = "LIKES"
+	}
+
+	limit, cursor, ok := readChirpsPage(w, r)
+	if !ok {
+		return
+	}
+
+	var cursorCreatedAt sql.NullTime
+	var cursorId uuid.NullUUID
+	var cursorLikeCount sql.NullInt32
+	if cursor != nil {
+		cursorCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		cursorId = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+		cursorLikeCount = sql.NullInt32{Int32: cursor.LikeCount, Valid: true}
 	}
 
 	var chirps []database.Chirp
 	var err error
 
+	// one extra row tells us whether there is a next page
+	rowLimit := int32(limit + 1)
+
 	if authorId == "" {
-		chirps, err = cfg.DbQueries.GetAllChirps(r.Context(), sort)
+		chirps, err = cfg.DbQueries.GetAllChirps(r.Context(), database.GetAllChirpsParams{
+			CursorCreatedAt: cursorCreatedAt,
+			Sort:            sort,
+			CursorID:        cursorId,
+			CursorLikeCount: cursorLikeCount,
+			RowLimit:        rowLimit,
+		})
 	} else {
-		authorIdUUID, _ := uuid.Parse(authorId)
+		authorIdUUID, parseErr := uuid.Parse(authorId)
+		if parseErr != nil {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid author_id: %s", parseErr), parseErr)
+			return
+		}
 		chirps, err = cfg.DbQueries.GetAllChirpsByAuthor(r.Context(), database.GetAllChirpsByAuthorParams{
-			UserID: authorIdUUID,
-			Sort:   sort,
+			UserID:          authorIdUUID,
+			CursorCreatedAt: cursorCreatedAt,
+			Sort:            sort,
+			CursorID:        cursorId,
+			CursorLikeCount: cursorLikeCount,
+			RowLimit:        rowLimit,
 		})
 	}
 
@@ -39,7 +78,18 @@ func GetAllChirps(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, chirps)
+	page := types.ChirpsPage{}
+	if len(chirps) > limit {
+		chirps = chirps[:limit]
+		page.NextCursor = encodeChirpCursor(chirps[limit-1])
+	}
+	page.Chirps, err = chirpResponses(r, cfg, chirps)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error looking for likes: %s", err), err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, page)
 }
 
 func GetChirpById(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
@@ -55,7 +105,13 @@ func GetChirpById(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, chirp)
+	res, err := chirpResponses(r, cfg, []database.Chirp{chirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error looking for likes: %s", err), err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, res[0])
 }
 
 func DeleteChirpByIdHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
@@ -65,9 +121,11 @@ func DeleteChirpByIdHandler(w http.ResponseWriter, r *http.Request, cfg *types.A
 		return
 	}
 
-	// this has been already checked
-	token, _ := auth.GetBearerToken(r.Header)
-	userId, _ := auth.ValidateJWT(token, cfg.Secret)
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+	userId := principal.UserID
 
 	chirp, err := cfg.DbQueries.GetChirpById(r.Context(), id)
 
@@ -85,6 +143,13 @@ func DeleteChirpByIdHandler(w http.ResponseWriter, r *http.Request, cfg *types.A
 		ID:     id,
 		UserID: userId,
 	})
+	// chirps with replies are kept without their body, so threads stay whole
+	if errors.Is(err, sql.ErrNoRows) {
+		chirpId, err = cfg.DbQueries.MarkChirpDeleted(r.Context(), database.MarkChirpDeletedParams{
+			ID:     id,
+			UserID: userId,
+		})
+	}
 
 	if chirpId == uuid.Nil || err != nil {
 		respondWithError(w, http.StatusForbidden, fmt.Sprintf("Error: %s", err), err)
@@ -95,13 +160,25 @@ func DeleteChirpByIdHandler(w http.ResponseWriter, r *http.Request, cfg *types.A
 }
 
 func AddChirp(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
-	// this has been already checked
-	token, _ := auth.GetBearerToken(r.Header)
-	userId, _ := auth.ValidateJWT(token, cfg.Secret)
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+	userId := principal.UserID
+
+	user, err := cfg.DbQueries.GetUserById(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error looking for user: %s", err), err)
+		return
+	}
+	if !user.VerifiedAt.Valid {
+		respondWithError(w, http.StatusForbidden, "Error: verify your email before posting chirps", nil)
+		return
+	}
 
 	decoder := json.NewDecoder(r.Body)
 	addChirp := types.AddChirpReq{}
-	err := decoder.Decode(&addChirp)
+	err = decoder.Decode(&addChirp)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error decoding chirp: %s", err), err)
 		return
@@ -117,37 +194,75 @@ func AddChirp(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
 		return
 	}
 
-	chirp, err := cfg.DbQueries.CreateChirp(r.Context(), database.CreateChirpParams{
-		UserID: userId,
-		Body:   censorChrip(addChirp.Body),
-	})
+	var replyToId uuid.NullUUID
+	if addChirp.ReplyToID != nil {
+		// chirps of deleted accounts and deleted chirps can't be replied to
+		_, err = cfg.DbQueries.GetChirpById(r.Context(), *addChirp.ReplyToID)
+		if errors.Is(err, sql.ErrNoRows) {
+			respondWithError(w, http.StatusBadRequest, "Error: the chirp being replied to does not exist", err)
+			return
+		}
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error getting chirp: %s", err), err)
+			return
+		}
+		replyToId = uuid.NullUUID{UUID: *addChirp.ReplyToID, Valid: true}
+	}
 
+	moderationResult, err := cfg.Moderator.Moderate(r.Context(), addChirp.Body)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error saving chirp: %s", err), err)
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error moderating chirp: %s", err), err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusCreated, chirp)
-}
+	if moderationResult.Rejected() {
+		respondWithError(w, http.StatusUnprocessableEntity, "Chirp rejected by moderation", nil)
+		return
+	}
 
-func censorChrip(chirp string) string {
-	censoredWords := getCensoredWords()
+	// masking a word shorter than the mask makes the chirp longer
+	if len(moderationResult.Text) > 140 {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Chirp too long once moderated: %d chars long", len(moderationResult.Text)), nil)
+		return
+	}
 
-	cleanedChirp := strings.Split(chirp, " ")
+	// a chirp is never published without the record of how it was moderated
+	var chirp database.Chirp
+	err = cfg.DbQueries.InTx(r.Context(), func(q database.Querier) error {
+		var err error
+		chirp, err = q.CreateChirp(r.Context(), database.CreateChirpParams{
+			UserID:    userId,
+			Body:      moderationResult.Text,
+			ReplyToID: replyToId,
+		})
+		if err != nil {
+			return err
+		}
 
-	for i, word := range cleanedChirp {
-		if censoredWords[strings.ToLower(word)] {
-			cleanedChirp[i] = "****"
+		for _, decision := range moderationResult.Decisions {
+			_, err = q.CreateChirpModerationDecision(r.Context(), database.CreateChirpModerationDecisionParams{
+				ChirpID:     chirp.ID,
+				Rule:        decision.Rule,
+				Action:      string(decision.Action),
+				MatchedText: decision.Match,
+			})
+			if err != nil {
+				return fmt.Errorf("recording moderation decision: %w", err)
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error saving chirp: %s", err), err)
+		return
 	}
 
-	return strings.Join(cleanedChirp, " ")
-}
+	// a new chirp is shaped like the same chirp read back
+	res, err := chirpResponses(r, cfg, []database.Chirp{chirp})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error looking for likes: %s", err), err)
+		return
+	}
 
-func getCensoredWords() map[string]bool {
-	censoredWords := make(map[string]bool)
-	censoredWords["kerfuffle"] = true
-	censoredWords["sharbert"] = true
-	censoredWords["fornax"] = true
-	return censoredWords
+	respondWithJSON(w, http.StatusCreated, res[0])
 }
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kevinjimenez96/chirpy/internal/auth"
+	"github.com/kevinjimenez96/chirpy/internal/database"
+	"github.com/kevinjimenez96/chirpy/internal/types"
+)
+
+// decisionlessQuerier fails to record moderation decisions.
+type decisionlessQuerier struct {
+	database.Querier
+}
+
+func (decisionlessQuerier) CreateChirpModerationDecision(ctx context.Context, arg database.CreateChirpModerationDecisionParams) (database.ChirpModerationDecision, error) {
+	return database.ChirpModerationDecision{}, errors.New("disk full")
+}
+
+func TestAddChirpRecordsModeration(t *testing.T) {
+	server, cfg := testServer(t)
+	_, token := createTestUser(t, cfg, "a@example.com", "password1")
+
+	chirp := database.Chirp{}
+	status := doJSON(t, http.MethodPost, server.URL+"/api/chirps", token, types.AddChirpReq{Chirp: types.Chirp{Body: "what a kerfuffle"}}, &chirp)
+	if status != http.StatusCreated || chirp.Body != "what a ****" {
+		t.Fatalf("POST /api/chirps = %d, %+v, want 201 with the word masked", status, chirp)
+	}
+	decisions, _ := cfg.DbQueries.GetChirpModerationDecisions(context.Background(), chirp.ID)
+	if len(decisions) != 1 || decisions[0].MatchedText != "kerfuffle" {
+		t.Errorf("GetChirpModerationDecisions() = %+v, want the masked word", decisions)
+	}
+
+	// a chirp whose decisions can't be recorded isn't published either
+	cfg.DbQueries = failingTxStore{cfg.DbQueries, func(q database.Querier) database.Querier {
+		return decisionlessQuerier{q}
+	}}
+	status = doJSON(t, http.MethodPost, server.URL+"/api/chirps", token, types.AddChirpReq{Chirp: types.Chirp{Body: "another kerfuffle"}}, nil)
+	if status != http.StatusInternalServerError {
+		t.Errorf("POST /api/chirps without recording decisions = %d, want 500", status)
+	}
+	page := types.ChirpsPage{}
+	doJSON(t, http.MethodGet, server.URL+"/api/chirps", "", nil, &page)
+	if len(page.Chirps) != 1 {
+		t.Errorf("GET /api/chirps = %+v, want only the first chirp", page)
+	}
+}
+
+func TestGetAllChirpsPages(t *testing.T) {
+	server, cfg := testServer(t)
+	_, token := createTestUser(t, cfg, "a@example.com", "password1")
+
+	for _, query := range []string{
+		"limit=abc",
+		"limit=0",
+		"limit=101",
+		"cursor=not-base64!",
+		"cursor=" + base64.RawURLEncoding.EncodeToString([]byte("yesterday|someone|0")),
+	} {
+		if status := doJSON(t, http.MethodGet, server.URL+"/api/chirps?"+query, "", nil, nil); status != http.StatusBadRequest {
+			t.Errorf("GET /api/chirps?%s = %d, want 400", query, status)
+		}
+	}
+
+	// two chirps tie on one like and three on none; paging must still visit
+	// each exactly once, most liked first
+	var ids []uuid.UUID
+	for i := range 5 {
+		chirp := database.Chirp{}
+		if status := doJSON(t, http.MethodPost, server.URL+"/api/chirps", token, types.AddChirpReq{Chirp: types.Chirp{Body: fmt.Sprintf("chirp %d", i)}}, &chirp); status != http.StatusCreated {
+			t.Fatalf("POST /api/chirps = %d, want 201", status)
+		}
+		ids = append(ids, chirp.ID)
+	}
+	for _, id := range ids[1:3] {
+		doJSON(t, http.MethodPost, server.URL+"/api/chirps/"+id.String()+"/like", token, nil, nil)
+	}
+
+	var seen []types.ChirpRes
+	url := server.URL + "/api/chirps?sort=likes&limit=2"
+	for range len(ids) {
+		page := types.ChirpsPage{}
+		if status := doJSON(t, http.MethodGet, url, "", nil, &page); status != http.StatusOK {
+			t.Fatalf("GET %s = %d, want 200", url, status)
+		}
+		seen = append(seen, page.Chirps...)
+		if page.NextCursor == "" {
+			break
+		}
+		url = server.URL + "/api/chirps?sort=likes&limit=2&cursor=" + page.NextCursor
+	}
+	visited := map[uuid.UUID]bool{}
+	for i, chirp := range seen {
+		visited[chirp.ID] = true
+		wantLikes := int32(0)
+		if i < 2 {
+			wantLikes = 1
+		}
+		if chirp.LikeCount != wantLikes {
+			t.Errorf("chirp %d of sort=likes has %d likes, want %d", i, chirp.LikeCount, wantLikes)
+		}
+	}
+	if len(seen) != len(ids) || len(visited) != len(ids) {
+		t.Errorf("paging sort=likes visited %d chirps, %d distinct, want each of %d once", len(seen), len(visited), len(ids))
+	}
+}
+
+func TestAddChirpLengthOnceMasked(t *testing.T) {
+	server, cfg := testServer(t)
+	_, token := createTestUser(t, cfg, "a@example.com", "password1")
+	admin, _ := auth.MakeJWT(uuid.New(), auth.RoleAdmin, cfg.Keys, time.Hour)
+	if status := doJSON(t, http.MethodPost, server.URL+"/admin/moderation/words", admin, types.AddBannedWordReq{Word: "ab"}, nil); status != http.StatusCreated {
+		t.Fatalf("POST word = %d, want 201", status)
+	}
+
+	// 138 characters, but every "ab" becomes "****"
+	body := strings.TrimSpace(strings.Repeat("ab ", 46))
+	if status := doJSON(t, http.MethodPost, server.URL+"/api/chirps", token, types.AddChirpReq{Chirp: types.Chirp{Body: body}}, nil); status != http.StatusBadRequest {
+		t.Errorf("POST a chirp too long once masked = %d, want 400", status)
+	}
+	page := types.ChirpsPage{}
+	doJSON(t, http.MethodGet, server.URL+"/api/chirps", "", nil, &page)
+	if len(page.Chirps) != 0 {
+		t.Errorf("GET /api/chirps = %+v, want nothing saved", page.Chirps)
+	}
+
+	chirp := database.Chirp{}
+	if status := doJSON(t, http.MethodPost, server.URL+"/api/chirps", token, types.AddChirpReq{Chirp: types.Chirp{Body: "ab cd"}}, &chirp); status != http.StatusCreated || chirp.Body != "**** cd" {
+		t.Errorf("POST a short chirp = %d, %q, want 201 with the word masked", status, chirp.Body)
+	}
+}
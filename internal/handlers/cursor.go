@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kevinjimenez96/chirpy/internal/database"
+)
+
+// chirpCursor is the keyset position of a chirp in a listing. It is keyed on
+// (created_at, id) so pages stay stable while new chirps are inserted, and
+// on (like_count, created_at, id) when sorting by likes.
+type chirpCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+	LikeCount int32
+}
+
+// readChirpsLimit reads the limit query parameter of a chirp listing or
+// search. If it is invalid it responds with a 400 and returns false.
+func readChirpsLimit(w http.ResponseWriter, r *http.Request) (int, bool) {
+	limitParam := r.URL.Query().Get("limit")
+	if limitParam == "" {
+		return defaultChirpsPageSize, true
+	}
+	limit, err := strconv.Atoi(limitParam)
+	if err != nil || limit < 1 || limit > maxChirpsPageSize {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid limit: must be between 1 and %d", maxChirpsPageSize), err)
+		return 0, false
+	}
+	return limit, true
+}
+
+// readChirpsPage reads the limit and cursor query parameters of a chirp
+// listing. The cursor is nil on the first page. If either is invalid it
+// responds with a 400 and returns false.
+func readChirpsPage(w http.ResponseWriter, r *http.Request) (int, *chirpCursor, bool) {
+	limit, ok := readChirpsLimit(w, r)
+	if !ok {
+		return 0, nil, false
+	}
+
+	cursorParam := r.URL.Query().Get("cursor")
+	if cursorParam == "" {
+		return limit, nil, true
+	}
+	cursor, err := decodeChirpCursor(cursorParam)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid cursor: %s", err), err)
+		return 0, nil, false
+	}
+	return limit, &cursor, true
+}
+
+func encodeChirpCursor(chirp database.Chirp) string {
+	raw := chirp.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + chirp.ID.String() + "|" + strconv.Itoa(int(chirp.LikeCount))
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeChirpCursor(cursor string) (chirpCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return chirpCursor{}, errors.New("malformed cursor")
+	}
+
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 {
+		return chirpCursor{}, errors.New("malformed cursor")
+	}
+	createdAt, id, likeCount := parts[0], parts[1], parts[2]
+
+	parsedCreatedAt, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return chirpCursor{}, errors.New("malformed cursor")
+	}
+	parsedId, err := uuid.Parse(id)
+	if err != nil {
+		return chirpCursor{}, errors.New("malformed cursor")
+	}
+	parsedLikeCount, err := strconv.ParseInt(likeCount, 10, 32)
+	if err != nil {
+		return chirpCursor{}, errors.New("malformed cursor")
+	}
+
+	return chirpCursor{CreatedAt: parsedCreatedAt, ID: parsedId, LikeCount: int32(parsedLikeCount)}, nil
+}
@@ -0,0 +1,14 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/kevinjimenez96/chirpy/internal/types"
+)
+
+// JWKSHandler publishes the public keys that verify access tokens so other
+// services can check them without sharing a secret.
+func JWKSHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	respondWithJSON(w, http.StatusOK, cfg.Keys.JWKS())
+}
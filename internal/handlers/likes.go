@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/kevinjimenez96/chirpy/internal/auth"
+	"github.com/kevinjimenez96/chirpy/internal/database"
+	"github.com/kevinjimenez96/chirpy/internal/types"
+)
+
+// chirpResponses prepares chirps for the caller, telling an authenticated
+// one which of them they liked.
+func chirpResponses(r *http.Request, cfg *types.ApiConfig, chirps []database.Chirp) ([]types.ChirpRes, error) {
+	res := make([]types.ChirpRes, len(chirps))
+	for i, chirp := range chirps {
+		res[i].Chirp = chirp
+		res[i].Deleted = chirp.DeletedAt.Valid
+	}
+
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok || len(chirps) == 0 {
+		return res, nil
+	}
+
+	ids := make([]uuid.UUID, len(chirps))
+	for i, chirp := range chirps {
+		ids[i] = chirp.ID
+	}
+	liked, err := cfg.DbQueries.ListLikedChirpIDs(r.Context(), database.ListLikedChirpIDsParams{
+		UserID:   principal.UserID,
+		ChirpIds: ids,
+	})
+	if err != nil {
+		return nil, err
+	}
+	likedSet := make(map[uuid.UUID]bool, len(liked))
+	for _, id := range liked {
+		likedSet[id] = true
+	}
+	for i := range res {
+		likedByMe := likedSet[res[i].ID]
+		res[i].LikedByMe = &likedByMe
+	}
+	return res, nil
+}
+
+// LikeChirpHandler likes a chirp for the caller. Liking it again changes
+// nothing.
+func LikeChirpHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	setChirpLike(w, r, cfg, true)
+}
+
+// UnlikeChirpHandler takes the caller's like back, if they liked the chirp.
+func UnlikeChirpHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	setChirpLike(w, r, cfg, false)
+}
+
+// setChirpLike makes the caller like the chirp or not, and responds with
+// the chirp's new like count.
+func setChirpLike(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig, like bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid chirp id: %s", err), err)
+		return
+	}
+
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	// chirps of deleted accounts are hidden, so they can't be liked either
+	_, err = cfg.DbQueries.GetChirpById(r.Context(), id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, fmt.Sprintf("Error getting chirp: %s", err), err)
+		return
+	}
+
+	if like {
+		err = cfg.DbQueries.LikeChirp(r.Context(), database.LikeChirpParams{ChirpID: id, UserID: principal.UserID})
+	} else {
+		err = cfg.DbQueries.UnlikeChirp(r.Context(), database.UnlikeChirpParams{ChirpID: id, UserID: principal.UserID})
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error saving like: %s", err), err)
+		return
+	}
+
+	chirp, err := cfg.DbQueries.GetChirpById(r.Context(), id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, fmt.Sprintf("Error getting chirp: %s", err), err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, types.ChirpRes{Chirp: chirp, LikedByMe: &like})
+}
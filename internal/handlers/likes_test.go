@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kevinjimenez96/chirpy/internal/auth"
+	"github.com/kevinjimenez96/chirpy/internal/database"
+	"github.com/kevinjimenez96/chirpy/internal/types"
+)
+
+func TestChirpLikes(t *testing.T) {
+	server, cfg := testServer(t)
+	store := cfg.DbQueries
+
+	ctx := context.Background()
+	user, _ := store.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com", HashedPassword: "hash"})
+	token, _ := auth.MakeJWT(user.ID, auth.RoleUser, cfg.Keys, time.Hour)
+	popular, _ := store.CreateChirp(ctx, database.CreateChirpParams{UserID: user.ID, Body: "popular"})
+	store.CreateChirp(ctx, database.CreateChirpParams{UserID: user.ID, Body: "ignored"})
+	likeURL := server.URL + "/api/chirps/" + popular.ID.String() + "/like"
+
+	// liking is idempotent
+	for range 2 {
+		res := types.ChirpRes{}
+		status := doJSON(t, http.MethodPost, likeURL, token, nil, &res)
+		if status != http.StatusOK || res.LikeCount != 1 || res.LikedByMe == nil || !*res.LikedByMe {
+			t.Fatalf("POST like = %d, %+v, want 200 with one like by me", status, res)
+		}
+	}
+
+	page := types.ChirpsPage{}
+	doJSON(t, http.MethodGet, server.URL+"/api/chirps?sort=likes", token, nil, &page)
+	if len(page.Chirps) != 2 || page.Chirps[0].ID != popular.ID || !*page.Chirps[0].LikedByMe || *page.Chirps[1].LikedByMe {
+		t.Errorf("GET /api/chirps?sort=likes = %+v, want the liked chirp first", page)
+	}
+
+	// anonymous callers see counts, but nothing is liked by them
+	res := types.ChirpRes{}
+	status := doJSON(t, http.MethodGet, server.URL+"/api/chirps/"+popular.ID.String(), "", nil, &res)
+	if status != http.StatusOK || res.LikeCount != 1 || res.LikedByMe != nil {
+		t.Errorf("anonymous GET chirp = %d, %+v, want one like and no liked_by_me", status, res)
+	}
+	if status := doJSON(t, http.MethodGet, server.URL+"/api/chirps", "invalid", nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("GET /api/chirps with an invalid token = %d, want 401", status)
+	}
+
+	for range 2 {
+		res := types.ChirpRes{}
+		status := doJSON(t, http.MethodDelete, likeURL, token, nil, &res)
+		if status != http.StatusOK || res.LikeCount != 0 || *res.LikedByMe {
+			t.Fatalf("DELETE like = %d, %+v, want 200 with no likes", status, res)
+		}
+	}
+
+	if status := doJSON(t, http.MethodPost, server.URL+"/api/chirps/"+user.ID.String()+"/like", token, nil, nil); status != http.StatusNotFound {
+		t.Errorf("POST like of an unknown chirp = %d, want 404", status)
+	}
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/kevinjimenez96/chirpy/internal/auth"
+	"github.com/kevinjimenez96/chirpy/internal/database"
+	"github.com/kevinjimenez96/chirpy/internal/moderation"
+	"github.com/kevinjimenez96/chirpy/internal/types"
+)
+
+// adminApiKeyActor is recorded as the author of changes made with the admin
+// API key.
+const adminApiKeyActor = "admin-api-key"
+
+// adminActor describes who is making an admin request: "user:<id>" for admin
+// users, adminApiKeyActor for the admin API key.
+func adminActor(r *http.Request) string {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		return adminApiKeyActor
+	}
+	return "user:" + principal.UserID.String()
+}
+
+func ListBannedWordsHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	bannedWords, err := cfg.DbQueries.ListBannedWords(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error listing banned words: %s", err), err)
+		return
+	}
+
+	if bannedWords == nil {
+		bannedWords = []database.BannedWord{}
+	}
+
+	respondWithJSON(w, http.StatusOK, bannedWords)
+}
+
+func AddBannedWordHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	decoder := json.NewDecoder(r.Body)
+	addBannedWordReq := types.AddBannedWordReq{}
+	err := decoder.Decode(&addBannedWordReq)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Error decoding banned word request: %s", err), err)
+		return
+	}
+
+	// words are matched token by token, so anything that isn't a single
+	// token could never match
+	tokens := moderation.Tokenize(addBannedWordReq.Word)
+	if len(tokens) != 1 || tokens[0].Text != addBannedWordReq.Word {
+		respondWithError(w, http.StatusBadRequest, "Invalid word: must be a single word without spaces or punctuation", nil)
+		return
+	}
+
+	action := moderation.ActionMask
+	if addBannedWordReq.Action != "" {
+		action, err = moderation.ParseAction(addBannedWordReq.Action)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid action: %s", err), err)
+			return
+		}
+	}
+
+	// the list never changes without a record of who changed it
+	actor := adminActor(r)
+	var bannedWord database.BannedWord
+	err = cfg.DbQueries.InTx(r.Context(), func(q database.Querier) error {
+		var err error
+		bannedWord, err = q.UpsertBannedWord(r.Context(), database.UpsertBannedWordParams{
+			Word:      strings.ToLower(addBannedWordReq.Word),
+			Action:    string(action),
+			CreatedBy: actor,
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = q.CreateBannedWordChange(r.Context(), database.CreateBannedWordChangeParams{
+			Word:      bannedWord.Word,
+			Change:    "add",
+			Action:    bannedWord.Action,
+			ChangedBy: actor,
+		})
+		if err != nil {
+			return fmt.Errorf("recording banned word change: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error saving banned word: %s", err), err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, bannedWord)
+}
+
+func DeleteBannedWordHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	word := strings.ToLower(r.PathValue("word"))
+
+	actor := adminActor(r)
+	err := cfg.DbQueries.InTx(r.Context(), func(q database.Querier) error {
+		bannedWord, err := q.DeleteBannedWord(r.Context(), word)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.CreateBannedWordChange(r.Context(), database.CreateBannedWordChangeParams{
+			Word:      bannedWord.Word,
+			Change:    "remove",
+			Action:    bannedWord.Action,
+			ChangedBy: actor,
+		})
+		if err != nil {
+			return fmt.Errorf("recording banned word change: %w", err)
+		}
+		return nil
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, fmt.Sprintf("Banned word not found: %s", word), nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error deleting banned word: %s", err), err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func ListBannedWordChangesHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	changes, err := cfg.DbQueries.ListBannedWordChanges(r.Context())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error listing banned word changes: %s", err), err)
+		return
+	}
+
+	if changes == nil {
+		changes = []database.BannedWordChange{}
+	}
+
+	respondWithJSON(w, http.StatusOK, changes)
+}
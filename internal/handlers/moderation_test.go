@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kevinjimenez96/chirpy/internal/auth"
+	"github.com/kevinjimenez96/chirpy/internal/database"
+	"github.com/kevinjimenez96/chirpy/internal/types"
+)
+
+// unauditedQuerier fails to record banned word changes.
+type unauditedQuerier struct {
+	database.Querier
+}
+
+func (unauditedQuerier) CreateBannedWordChange(ctx context.Context, arg database.CreateBannedWordChangeParams) (database.BannedWordChange, error) {
+	return database.BannedWordChange{}, errors.New("disk full")
+}
+
+func TestBannedWordChanges(t *testing.T) {
+	server, cfg := testServer(t)
+	admin, _ := createTestUser(t, cfg, "admin@example.com", "password1")
+	token, _ := auth.MakeJWT(admin.ID, auth.RoleAdmin, cfg.Keys, time.Hour)
+
+	if status := doJSON(t, http.MethodPost, server.URL+"/admin/moderation/words", token, types.AddBannedWordReq{Word: "fiddlesticks"}, nil); status != http.StatusCreated {
+		t.Fatalf("POST word = %d, want 201", status)
+	}
+	if status := doJSON(t, http.MethodDelete, server.URL+"/admin/moderation/words/fiddlesticks", token, nil, nil); status != http.StatusNoContent {
+		t.Fatalf("DELETE word = %d, want 204", status)
+	}
+	if status := doJSON(t, http.MethodDelete, server.URL+"/admin/moderation/words/fiddlesticks", token, nil, nil); status != http.StatusNotFound {
+		t.Errorf("DELETE unknown word = %d, want 404", status)
+	}
+
+	changes := []database.BannedWordChange{}
+	doJSON(t, http.MethodGet, server.URL+"/admin/moderation/words/changes", token, nil, &changes)
+	if len(changes) != 2 {
+		t.Fatalf("GET changes = %+v, want an add and a remove", changes)
+	}
+	for _, change := range changes {
+		if change.Word != "fiddlesticks" || change.ChangedBy != "user:"+admin.ID.String() {
+			t.Errorf("change = %+v, want fiddlesticks changed by the admin", change)
+		}
+	}
+
+	// a word is not banned when its change can't be recorded
+	store := cfg.DbQueries
+	cfg.DbQueries = failingTxStore{store, func(q database.Querier) database.Querier {
+		return unauditedQuerier{q}
+	}}
+	if status := doJSON(t, http.MethodPost, server.URL+"/admin/moderation/words", token, types.AddBannedWordReq{Word: "balderdash"}, nil); status != http.StatusInternalServerError {
+		t.Fatalf("POST word without its change = %d, want 500", status)
+	}
+	cfg.DbQueries = store
+	words := []database.BannedWord{}
+	doJSON(t, http.MethodGet, server.URL+"/admin/moderation/words", token, nil, &words)
+	for _, word := range words {
+		if word.Word == "balderdash" {
+			t.Errorf("GET words = %+v, want balderdash rolled back", words)
+		}
+	}
+}
@@ -0,0 +1,639 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kevinjimenez96/chirpy/internal/auth"
+	"github.com/kevinjimenez96/chirpy/internal/database"
+	"github.com/kevinjimenez96/chirpy/internal/types"
+)
+
+// oauthAccessTokenLifetime is how long access tokens issued to OAuth clients
+// last.
+const oauthAccessTokenLifetime = time.Hour
+
+// maxOAuthClientNameLength caps the name shown to users on the consent
+// screen.
+const maxOAuthClientNameLength = 100
+
+// maxRedirectURIs caps how many redirect URIs a client can register.
+const maxRedirectURIs = 10
+
+func oauthClientRes(client database.OauthClient) types.OAuthClientRes {
+	return types.OAuthClientRes{
+		ID:           client.ID,
+		Name:         client.Name,
+		RedirectURIs: client.RedirectUris,
+		Confidential: client.SecretHash.Valid,
+		CreatedAt:    client.CreatedAt,
+	}
+}
+
+// validRedirectURI reports whether uri can be registered: an absolute https
+// URL without a fragment, or plain http to the loopback interface for
+// clients running on the user's machine.
+func validRedirectURI(uri string) bool {
+	u, err := url.Parse(uri)
+	if err != nil || u.Host == "" || u.Fragment != "" {
+		return false
+	}
+	switch u.Scheme {
+	case "https":
+		return true
+	case "http":
+		host := u.Hostname()
+		return host == "localhost" || net.ParseIP(host).IsLoopback()
+	}
+	return false
+}
+
+func CreateOAuthClientHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	createOAuthClientReq := types.CreateOAuthClientReq{}
+	err := decoder.Decode(&createOAuthClientReq)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Error decoding create OAuth client request: %s", err), err)
+		return
+	}
+
+	name := strings.TrimSpace(createOAuthClientReq.Name)
+	if name == "" || len(name) > maxOAuthClientNameLength {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Error: name must be 1 to %d characters long", maxOAuthClientNameLength), nil)
+		return
+	}
+
+	redirectURIs := createOAuthClientReq.RedirectURIs
+	if len(redirectURIs) == 0 || len(redirectURIs) > maxRedirectURIs {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Error: register 1 to %d redirect URIs", maxRedirectURIs), nil)
+		return
+	}
+	for _, uri := range redirectURIs {
+		if !validRedirectURI(uri) {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Error: invalid redirect URI %q, use https or http to localhost, without a fragment", uri), nil)
+			return
+		}
+	}
+
+	var secret string
+	var secretHash sql.NullString
+	if createOAuthClientReq.Confidential {
+		secret, err = auth.MakeOAuthSecret()
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error generating client secret: %s", err), err)
+			return
+		}
+		secretHash = sql.NullString{String: auth.HashOAuthSecret(secret), Valid: true}
+	}
+
+	client, err := cfg.DbQueries.CreateOauthClient(r.Context(), database.CreateOauthClientParams{
+		OwnerID:      principal.UserID,
+		Name:         name,
+		RedirectUris: redirectURIs,
+		SecretHash:   secretHash,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error saving OAuth client: %s", err), err)
+		return
+	}
+
+	res := oauthClientRes(client)
+	res.Secret = secret
+	respondWithJSON(w, http.StatusCreated, res)
+}
+
+func ListOAuthClientsHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	clients, err := cfg.DbQueries.ListOauthClientsByOwner(r.Context(), principal.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error listing OAuth clients: %s", err), err)
+		return
+	}
+
+	res := make([]types.OAuthClientRes, 0, len(clients))
+	for _, client := range clients {
+		res = append(res, oauthClientRes(client))
+	}
+
+	respondWithJSON(w, http.StatusOK, res)
+}
+
+// DeleteOAuthClientHandler deletes a client along with every grant users
+// made to it.
+func DeleteOAuthClientHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid OAuth client id: %s", err), err)
+		return
+	}
+
+	client, err := cfg.DbQueries.GetOauthClient(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) || err == nil && client.OwnerID != principal.UserID {
+		respondWithError(w, http.StatusNotFound, "OAuth client not found", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error looking for OAuth client: %s", err), err)
+		return
+	}
+
+	// the refresh tokens go with the client, but the access tokens issued
+	// with them have to be denylisted first
+	revokedTokens, err := cfg.DbQueries.RevokeAccessTokensForClient(r.Context(), uuid.NullUUID{UUID: client.ID, Valid: true})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error revoking OAuth client tokens: %s", err), err)
+		return
+	}
+	denyAccessTokens(cfg, revokedTokens)
+
+	_, err = cfg.DbQueries.DeleteOauthClientForOwner(r.Context(), database.DeleteOauthClientForOwnerParams{
+		ID:      client.ID,
+		OwnerID: principal.UserID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "OAuth client not found", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error deleting OAuth client: %s", err), err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// respondWithOAuthError responds in the error format of RFC 6749.
+func respondWithOAuthError(w http.ResponseWriter, code int, errorCode, description string) {
+	respondWithJSON(w, code, types.OAuthErrorRes{
+		Error:            errorCode,
+		ErrorDescription: description,
+	})
+}
+
+// authorizeRedirect returns the redirect URI of req, which has been checked
+// against the client, with params and the state of req added.
+func authorizeRedirect(req types.OAuthAuthorizeReq, params url.Values) string {
+	u, err := url.Parse(req.RedirectURI)
+	if err != nil {
+		return ""
+	}
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	if req.State != "" {
+		query.Set("state", req.State)
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// requireUserPrincipal is requirePrincipal for the consent routes, which only
+// the user can use, and not clients or API keys acting for them.
+func requireUserPrincipal(w http.ResponseWriter, r *http.Request) (auth.Principal, bool) {
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return auth.Principal{}, false
+	}
+	if principal.IsScoped() {
+		respondWithError(w, http.StatusForbidden, "Error: only the user can authorize OAuth clients", nil)
+		return auth.Principal{}, false
+	}
+	return principal, true
+}
+
+// checkAuthorizeRequest validates an authorization request and returns its
+// client and scopes. Until the client and redirect URI are known to match,
+// errors are shown to the user; after that they are passed on to the client
+// through redirect_to, as RFC 6749 requires, so that a bad redirect URI can
+// never be used to send users elsewhere.
+func checkAuthorizeRequest(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig, req types.OAuthAuthorizeReq) (database.OauthClient, []string, bool) {
+	clientID, err := uuid.Parse(req.ClientID)
+	if err != nil {
+		respondWithOAuthError(w, http.StatusBadRequest, "invalid_request", "invalid client_id")
+		return database.OauthClient{}, nil, false
+	}
+	client, err := cfg.DbQueries.GetOauthClient(r.Context(), clientID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithOAuthError(w, http.StatusBadRequest, "invalid_request", "unknown client_id")
+		return database.OauthClient{}, nil, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error looking for OAuth client: %s", err), err)
+		return database.OauthClient{}, nil, false
+	}
+	if !slices.Contains(client.RedirectUris, req.RedirectURI) {
+		respondWithOAuthError(w, http.StatusBadRequest, "invalid_request", "redirect_uri is not registered for this client")
+		return database.OauthClient{}, nil, false
+	}
+
+	redirectError := func(errorCode, description string) {
+		respondWithJSON(w, http.StatusBadRequest, types.OAuthErrorRes{
+			Error:            errorCode,
+			ErrorDescription: description,
+			RedirectTo: authorizeRedirect(req, url.Values{
+				"error":             {errorCode},
+				"error_description": {description},
+			}),
+		})
+	}
+	if req.ResponseType != "code" {
+		redirectError("unsupported_response_type", "only the code response type is supported")
+		return database.OauthClient{}, nil, false
+	}
+	// PKCE is required of every client, confidential ones included
+	if req.CodeChallengeMethod != "S256" || !auth.ValidCodeChallenge(req.CodeChallenge) {
+		redirectError("invalid_request", "an S256 code_challenge is required")
+		return database.OauthClient{}, nil, false
+	}
+	scopes, err := auth.ParseOAuthScope(req.Scope)
+	if err != nil {
+		redirectError("invalid_scope", err.Error())
+		return database.OauthClient{}, nil, false
+	}
+	return client, scopes, true
+}
+
+// OAuthAuthorizeHandler checks an authorization request and describes what
+// the client asks for, for the user to approve at POST /api/oauth/authorize.
+func OAuthAuthorizeHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	_, ok := requireUserPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	authorizeReq := types.OAuthAuthorizeReq{
+		ResponseType:        query.Get("response_type"),
+		ClientID:            query.Get("client_id"),
+		RedirectURI:         query.Get("redirect_uri"),
+		Scope:               query.Get("scope"),
+		State:               query.Get("state"),
+		CodeChallenge:       query.Get("code_challenge"),
+		CodeChallengeMethod: query.Get("code_challenge_method"),
+	}
+	client, scopes, ok := checkAuthorizeRequest(w, r, cfg, authorizeReq)
+	if !ok {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, types.OAuthConsentRes{
+		ClientID:    client.ID,
+		ClientName:  client.Name,
+		RedirectURI: authorizeReq.RedirectURI,
+		Scopes:      scopes,
+	})
+}
+
+// OAuthConsentHandler records the user's decision on an authorization
+// request. Either way the user is sent back to the client, with an
+// authorization code if they approved.
+func OAuthConsentHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	principal, ok := requireUserPrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	authorizeReq := types.OAuthAuthorizeReq{}
+	err := decoder.Decode(&authorizeReq)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Error decoding authorize request: %s", err), err)
+		return
+	}
+
+	client, scopes, ok := checkAuthorizeRequest(w, r, cfg, authorizeReq)
+	if !ok {
+		return
+	}
+
+	if !authorizeReq.Approve {
+		respondWithJSON(w, http.StatusOK, types.OAuthRedirectRes{
+			RedirectTo: authorizeRedirect(authorizeReq, url.Values{"error": {"access_denied"}}),
+		})
+		return
+	}
+
+	code, err := auth.MakeOAuthSecret()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error generating authorization code: %s", err), err)
+		return
+	}
+
+	_, err = cfg.DbQueries.CreateOauthAuthorizationCode(r.Context(), database.CreateOauthAuthorizationCodeParams{
+		CodeHash:      auth.HashOAuthSecret(code),
+		ClientID:      client.ID,
+		UserID:        principal.UserID,
+		RedirectUri:   authorizeReq.RedirectURI,
+		Scopes:        scopes,
+		CodeChallenge: authorizeReq.CodeChallenge,
+		FamilyID:      uuid.New(),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error saving authorization code: %s", err), err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, types.OAuthRedirectRes{
+		RedirectTo: authorizeRedirect(authorizeReq, url.Values{"code": {code}}),
+	})
+}
+
+// authenticateOAuthClient identifies the client calling the token or
+// introspection endpoint, by HTTP Basic auth or by the client_id and
+// client_secret form fields. Public clients only send their ID.
+func authenticateOAuthClient(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) (database.OauthClient, bool) {
+	clientID, secret, basic := r.BasicAuth()
+	if !basic {
+		clientID = r.PostForm.Get("client_id")
+		secret = r.PostForm.Get("client_secret")
+	}
+
+	invalidClient := func() {
+		if basic {
+			w.Header().Set("WWW-Authenticate", `Basic realm="chirpy"`)
+		}
+		respondWithOAuthError(w, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+	}
+
+	id, err := uuid.Parse(clientID)
+	if err != nil {
+		invalidClient()
+		return database.OauthClient{}, false
+	}
+	client, err := cfg.DbQueries.GetOauthClient(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		invalidClient()
+		return database.OauthClient{}, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error looking for OAuth client: %s", err), err)
+		return database.OauthClient{}, false
+	}
+
+	valid := secret == ""
+	if client.SecretHash.Valid {
+		valid = subtle.ConstantTimeCompare([]byte(auth.HashOAuthSecret(secret)), []byte(client.SecretHash.String)) == 1
+	}
+	if !valid {
+		invalidClient()
+		return database.OauthClient{}, false
+	}
+	return client, true
+}
+
+// OAuthTokenHandler is the token endpoint of RFC 6749, for the
+// authorization_code and refresh_token grants.
+func OAuthTokenHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	err := r.ParseForm()
+	if err != nil {
+		respondWithOAuthError(w, http.StatusBadRequest, "invalid_request", "invalid form body")
+		return
+	}
+
+	client, ok := authenticateOAuthClient(w, r, cfg)
+	if !ok {
+		return
+	}
+
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		exchangeAuthorizationCode(w, r, cfg, client)
+	case "refresh_token":
+		refreshOAuthToken(w, r, cfg, client)
+	default:
+		respondWithOAuthError(w, http.StatusBadRequest, "unsupported_grant_type", "use authorization_code or refresh_token")
+	}
+}
+
+func exchangeAuthorizationCode(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig, client database.OauthClient) {
+	codeHash := auth.HashOAuthSecret(r.PostForm.Get("code"))
+	code, err := cfg.DbQueries.UseOauthAuthorizationCode(r.Context(), codeHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		revokeReplayedCode(r, cfg, codeHash)
+		respondWithOAuthError(w, http.StatusBadRequest, "invalid_grant", "authorization code invalid, expired or already used")
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error using authorization code: %s", err), err)
+		return
+	}
+
+	if code.ClientID != client.ID || code.RedirectUri != r.PostForm.Get("redirect_uri") || !auth.VerifyPKCE(r.PostForm.Get("code_verifier"), code.CodeChallenge) {
+		respondWithOAuthError(w, http.StatusBadRequest, "invalid_grant", "authorization code was not issued for this request")
+		return
+	}
+
+	user, ok := oauthGrantUser(w, r, cfg, code.UserID)
+	if !ok {
+		return
+	}
+
+	accessToken, err := auth.MakeClientAccessToken(user.ID, client.ID, code.Scopes, cfg.Keys, oauthAccessTokenLifetime)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error creating access token: %s", err), err)
+		return
+	}
+
+	refreshToken, err := createRefreshToken(r, cfg, user.ID, code.FamilyID, sql.NullTime{}, accessToken, uuid.NullUUID{UUID: client.ID, Valid: true}, code.Scopes)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error creating refresh token: %s", err), err)
+		return
+	}
+
+	respondWithOAuthTokens(w, accessToken, refreshToken, code.Scopes)
+}
+
+// revokeReplayedCode revokes the tokens issued for an authorization code
+// presented again after it was used, since the code must have leaked.
+func revokeReplayedCode(r *http.Request, cfg *types.ApiConfig, codeHash string) {
+	code, err := cfg.DbQueries.GetOauthAuthorizationCode(r.Context(), codeHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return
+	}
+	if err != nil {
+		log.Printf("Error looking for authorization code: %s", err)
+		return
+	}
+	if !code.UsedAt.Valid {
+		return
+	}
+
+	log.Printf("Authorization code reuse detected for user %s, revoking family %s", code.UserID, code.FamilyID)
+	err = revokeSession(r, cfg, code.FamilyID)
+	if err != nil {
+		log.Printf("Error revoking token family %s: %s", code.FamilyID, err)
+	}
+}
+
+func refreshOAuthToken(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig, client database.OauthClient) {
+	refreshToken, err := getRefreshToken(r, cfg, r.PostForm.Get("refresh_token"))
+	if err != nil || refreshToken.ClientID != (uuid.NullUUID{UUID: client.ID, Valid: true}) {
+		respondWithOAuthError(w, http.StatusBadRequest, "invalid_grant", "refresh token invalid")
+		return
+	}
+
+	if refreshToken.ReplacedBy.Valid {
+		revokeOAuthTokenFamily(w, r, cfg, refreshToken)
+		return
+	}
+
+	if refreshToken.RevokedAt.Valid || time.Now().After(refreshToken.ExpiresAt) {
+		respondWithOAuthError(w, http.StatusBadRequest, "invalid_grant", "refresh token revoked or expired")
+		return
+	}
+
+	user, ok := oauthGrantUser(w, r, cfg, refreshToken.UserID)
+	if !ok {
+		return
+	}
+
+	accessToken, err := auth.MakeClientAccessToken(user.ID, client.ID, refreshToken.Scopes, cfg.Keys, oauthAccessTokenLifetime)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error creating access token: %s", err), err)
+		return
+	}
+
+	newRefreshToken, err := rotateRefreshToken(r, cfg, refreshToken, accessToken)
+	if errors.Is(err, errRefreshTokenReused) {
+		revokeOAuthTokenFamily(w, r, cfg, refreshToken)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error rotating refresh token: %s", err), err)
+		return
+	}
+
+	respondWithOAuthTokens(w, accessToken, newRefreshToken, refreshToken.Scopes)
+}
+
+// revokeOAuthTokenFamily is revokeRefreshTokenFamily for the token endpoint.
+func revokeOAuthTokenFamily(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig, refreshToken database.RefreshToken) {
+	log.Printf("Refresh token reuse detected for user %s, revoking family %s", refreshToken.UserID, refreshToken.FamilyID)
+
+	err := revokeSession(r, cfg, refreshToken.FamilyID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error revoking token family: %s", err), err)
+		return
+	}
+	respondWithOAuthError(w, http.StatusBadRequest, "invalid_grant", "refresh token revoked or expired")
+}
+
+// oauthGrantUser looks up the user who made a grant, who must still be
+// allowed to use Chirpy.
+func oauthGrantUser(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig, userID uuid.UUID) (database.User, bool) {
+	user, err := cfg.DbQueries.GetUserById(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error looking for user: %s", err), err)
+		return database.User{}, false
+	}
+	if user.BannedAt.Valid || user.DeletedAt.Valid {
+		respondWithOAuthError(w, http.StatusBadRequest, "invalid_grant", "account banned or deleted")
+		return database.User{}, false
+	}
+	return user, true
+}
+
+func respondWithOAuthTokens(w http.ResponseWriter, accessToken auth.AccessToken, refreshToken string, scopes []string) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	respondWithJSON(w, http.StatusOK, types.OAuthTokenRes{
+		AccessToken:  accessToken.Token,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(oauthAccessTokenLifetime.Seconds()),
+		RefreshToken: refreshToken,
+		Scope:        strings.Join(scopes, " "),
+	})
+}
+
+// OAuthIntrospectHandler implements token introspection (RFC 7662) for
+// confidential clients. Clients only learn about tokens issued to them.
+func OAuthIntrospectHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	err := r.ParseForm()
+	if err != nil {
+		respondWithOAuthError(w, http.StatusBadRequest, "invalid_request", "invalid form body")
+		return
+	}
+
+	client, ok := authenticateOAuthClient(w, r, cfg)
+	if !ok {
+		return
+	}
+	if !client.SecretHash.Valid {
+		respondWithOAuthError(w, http.StatusUnauthorized, "invalid_client", "introspection is only open to confidential clients")
+		return
+	}
+
+	res, err := introspectToken(r, cfg, client, r.PostForm.Get("token"))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error introspecting token: %s", err), err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	respondWithJSON(w, http.StatusOK, res)
+}
+
+// introspectToken describes token, an access or refresh token, if it is
+// active and was issued to client.
+func introspectToken(r *http.Request, cfg *types.ApiConfig, client database.OauthClient, token string) (types.OAuthIntrospectRes, error) {
+	if claims, err := auth.ParseJWT(token, cfg.Keys); err == nil {
+		principal := claims.Principal()
+		if principal.ClientID != client.ID {
+			return types.OAuthIntrospectRes{}, nil
+		}
+		revoked, err := cfg.Denylist.IsRevoked(r.Context(), principal)
+		if err != nil || revoked {
+			return types.OAuthIntrospectRes{}, err
+		}
+		return types.OAuthIntrospectRes{
+			Active:    true,
+			Scope:     claims.Scope,
+			ClientID:  claims.ClientID,
+			Subject:   claims.Subject,
+			TokenType: "access_token",
+			ExpiresAt: principal.ExpiresAt.Unix(),
+		}, nil
+	}
+
+	refreshToken, err := getRefreshToken(r, cfg, token)
+	if err != nil || refreshToken.ClientID != (uuid.NullUUID{UUID: client.ID, Valid: true}) {
+		return types.OAuthIntrospectRes{}, nil
+	}
+	if refreshToken.RevokedAt.Valid || time.Now().After(refreshToken.ExpiresAt) {
+		return types.OAuthIntrospectRes{}, nil
+	}
+	return types.OAuthIntrospectRes{
+		Active:    true,
+		Scope:     strings.Join(refreshToken.Scopes, " "),
+		ClientID:  client.ID.String(),
+		Subject:   refreshToken.UserID.String(),
+		TokenType: "refresh_token",
+		ExpiresAt: refreshToken.ExpiresAt.Unix(),
+	}, nil
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinjimenez96/chirpy/internal/auth"
+	"github.com/kevinjimenez96/chirpy/internal/database"
+	"github.com/kevinjimenez96/chirpy/internal/types"
+)
+
+func postForm(t *testing.T, target, clientID, secret string, form url.Values, res any) int {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(clientID, secret)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST %s error = %v", target, err)
+	}
+	defer resp.Body.Close()
+	json.NewDecoder(resp.Body).Decode(res)
+	return resp.StatusCode
+}
+
+func TestOAuthAuthorizationCodeFlow(t *testing.T) {
+	server, cfg := testServer(t)
+	user, _ := cfg.DbQueries.CreateUser(context.Background(), database.CreateUserParams{Email: "a@example.com", HashedPassword: "hash"})
+	userToken, _ := auth.MakeJWT(user.ID, auth.RoleUser, cfg.Keys, time.Hour)
+
+	const redirectURI = "http://127.0.0.1:8000/callback"
+	client := types.OAuthClientRes{}
+	status := doJSON(t, http.MethodPost, server.URL+"/api/oauth/clients", userToken, types.CreateOAuthClientReq{
+		Name:         "Chirp scheduler",
+		RedirectURIs: []string{redirectURI},
+		Confidential: true,
+	}, &client)
+	if status != http.StatusCreated || client.Secret == "" {
+		t.Fatalf("POST /api/oauth/clients = %d, %+v, want 201 with a secret", status, client)
+	}
+	clientID := client.ID.String()
+
+	verifier := strings.Repeat("v", 43)
+	sum := sha256.Sum256([]byte(verifier))
+	authorizeReq := types.OAuthAuthorizeReq{
+		ResponseType:        "code",
+		ClientID:            clientID,
+		RedirectURI:         redirectURI,
+		Scope:               "profile:read chirps:write",
+		State:               "xyz",
+		CodeChallenge:       base64.RawURLEncoding.EncodeToString(sum[:]),
+		CodeChallengeMethod: "S256",
+		Approve:             true,
+	}
+
+	query := url.Values{
+		"response_type":         {authorizeReq.ResponseType},
+		"client_id":             {authorizeReq.ClientID},
+		"redirect_uri":          {authorizeReq.RedirectURI},
+		"scope":                 {authorizeReq.Scope},
+		"code_challenge":        {authorizeReq.CodeChallenge},
+		"code_challenge_method": {authorizeReq.CodeChallengeMethod},
+	}
+	consent := types.OAuthConsentRes{}
+	status = doJSON(t, http.MethodGet, server.URL+"/api/oauth/authorize?"+query.Encode(), userToken, nil, &consent)
+	if status != http.StatusOK || consent.ClientName != "Chirp scheduler" || len(consent.Scopes) != 2 {
+		t.Fatalf("GET /api/oauth/authorize = %d, %+v, want the client and both scopes", status, consent)
+	}
+
+	redirect := types.OAuthRedirectRes{}
+	status = doJSON(t, http.MethodPost, server.URL+"/api/oauth/authorize", userToken, authorizeReq, &redirect)
+	redirectTo, _ := url.Parse(redirect.RedirectTo)
+	code := redirectTo.Query().Get("code")
+	if status != http.StatusOK || code == "" || redirectTo.Query().Get("state") != "xyz" {
+		t.Fatalf("POST /api/oauth/authorize = %d, %q, want a redirect with a code and the state", status, redirect.RedirectTo)
+	}
+
+	exchange := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+	}
+	if status := postForm(t, server.URL+"/api/oauth/token", clientID, "wrong", exchange, &types.OAuthErrorRes{}); status != http.StatusUnauthorized {
+		t.Errorf("POST /api/oauth/token with a wrong secret = %d, want 401", status)
+	}
+	tokens := types.OAuthTokenRes{}
+	status = postForm(t, server.URL+"/api/oauth/token", clientID, client.Secret, exchange, &tokens)
+	if status != http.StatusOK || tokens.AccessToken == "" || tokens.Scope != "chirps:write profile:read" {
+		t.Fatalf("POST /api/oauth/token = %d, %+v, want tokens for the granted scopes", status, tokens)
+	}
+
+	// the client can use the scopes it was granted and nothing else
+	if status := doJSON(t, http.MethodGet, server.URL+"/api/users/me", tokens.AccessToken, nil, nil); status != http.StatusOK {
+		t.Errorf("GET /api/users/me with profile:read = %d, want 200", status)
+	}
+	if status := doJSON(t, http.MethodGet, server.URL+"/api/sessions", tokens.AccessToken, nil, nil); status != http.StatusForbidden {
+		t.Errorf("GET /api/sessions with a client token = %d, want 403", status)
+	}
+	if status := doJSON(t, http.MethodGet, server.URL+"/api/oauth/authorize?"+query.Encode(), tokens.AccessToken, nil, nil); status != http.StatusForbidden {
+		t.Errorf("GET /api/oauth/authorize with a client token = %d, want 403", status)
+	}
+
+	introspection := types.OAuthIntrospectRes{}
+	postForm(t, server.URL+"/api/oauth/introspect", clientID, client.Secret, url.Values{"token": {tokens.AccessToken}}, &introspection)
+	if !introspection.Active || introspection.Subject != user.ID.String() || introspection.ClientID != clientID {
+		t.Errorf("introspection of the access token = %+v, want it active for the user and client", introspection)
+	}
+
+	refreshed := types.OAuthTokenRes{}
+	status = postForm(t, server.URL+"/api/oauth/token", clientID, client.Secret, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {tokens.RefreshToken},
+	}, &refreshed)
+	if status != http.StatusOK || refreshed.RefreshToken == tokens.RefreshToken || refreshed.Scope != tokens.Scope {
+		t.Fatalf("refresh_token grant = %d, %+v, want new tokens with the same scopes", status, refreshed)
+	}
+
+	// a code presented twice has leaked, so the tokens issued for it go
+	oauthErr := types.OAuthErrorRes{}
+	if status := postForm(t, server.URL+"/api/oauth/token", clientID, client.Secret, exchange, &oauthErr); status != http.StatusBadRequest || oauthErr.Error != "invalid_grant" {
+		t.Errorf("reused code = %d, %+v, want invalid_grant", status, oauthErr)
+	}
+	introspection = types.OAuthIntrospectRes{}
+	postForm(t, server.URL+"/api/oauth/introspect", clientID, client.Secret, url.Values{"token": {refreshed.AccessToken}}, &introspection)
+	if introspection.Active {
+		t.Errorf("introspection after code reuse = %+v, want the token revoked", introspection)
+	}
+}
+
+func TestOAuthAuthorizeErrors(t *testing.T) {
+	server, cfg := testServer(t)
+	user, _ := cfg.DbQueries.CreateUser(context.Background(), database.CreateUserParams{Email: "a@example.com", HashedPassword: "hash"})
+	userToken, _ := auth.MakeJWT(user.ID, auth.RoleUser, cfg.Keys, time.Hour)
+
+	client := types.OAuthClientRes{}
+	doJSON(t, http.MethodPost, server.URL+"/api/oauth/clients", userToken, types.CreateOAuthClientReq{
+		Name:         "Public app",
+		RedirectURIs: []string{"https://app.example.com/callback"},
+	}, &client)
+
+	sum := sha256.Sum256([]byte("verifier"))
+	valid := types.OAuthAuthorizeReq{
+		ResponseType:        "code",
+		ClientID:            client.ID.String(),
+		RedirectURI:         "https://app.example.com/callback",
+		Scope:               "chirps:write",
+		CodeChallenge:       base64.RawURLEncoding.EncodeToString(sum[:]),
+		CodeChallengeMethod: "S256",
+	}
+
+	tests := []struct {
+		name         string
+		modify       func(*types.OAuthAuthorizeReq)
+		wantError    string
+		wantRedirect bool
+	}{
+		{
+			name:      "Unregistered redirect URI",
+			modify:    func(req *types.OAuthAuthorizeReq) { req.RedirectURI = "https://evil.example.com/callback" },
+			wantError: "invalid_request",
+		},
+		{
+			name:         "Account scope",
+			modify:       func(req *types.OAuthAuthorizeReq) { req.Scope = auth.ScopeAccount },
+			wantError:    "invalid_scope",
+			wantRedirect: true,
+		},
+		{
+			name:         "Missing PKCE",
+			modify:       func(req *types.OAuthAuthorizeReq) { req.CodeChallenge = "" },
+			wantError:    "invalid_request",
+			wantRedirect: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid
+			tt.modify(&req)
+			res := types.OAuthErrorRes{}
+			status := doJSON(t, http.MethodPost, server.URL+"/api/oauth/authorize", userToken, req, &res)
+			if status != http.StatusBadRequest || res.Error != tt.wantError {
+				t.Errorf("POST /api/oauth/authorize = %d, %+v, want 400 %s", status, res, tt.wantError)
+			}
+			if got := strings.HasPrefix(res.RedirectTo, valid.RedirectURI); got != tt.wantRedirect {
+				t.Errorf("redirect_to = %q, want a redirect to the client: %v", res.RedirectTo, tt.wantRedirect)
+			}
+		})
+	}
+
+	// the user declining sends them back with access_denied
+	redirect := types.OAuthRedirectRes{}
+	doJSON(t, http.MethodPost, server.URL+"/api/oauth/authorize", userToken, valid, &redirect)
+	if redirectTo, _ := url.Parse(redirect.RedirectTo); redirectTo == nil || redirectTo.Query().Get("error") != "access_denied" {
+		t.Errorf("declined authorization redirect_to = %q, want error=access_denied", redirect.RedirectTo)
+	}
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/kevinjimenez96/chirpy/internal/auth"
+	"github.com/kevinjimenez96/chirpy/internal/database"
+	"github.com/kevinjimenez96/chirpy/internal/oidc"
+	"github.com/kevinjimenez96/chirpy/internal/types"
+)
+
+// oidcStateCookie holds the signed auth.OIDCState of a login in progress.
+const oidcStateCookie = "chirpy_oidc_state"
+
+// oidcLoginLifetime is how long users have to log in at the provider.
+const oidcLoginLifetime = 10 * time.Minute
+
+var (
+	errIdentityEmailUnverified = errors.New("the provider didn't verify the email address")
+	errIdentityEmailTaken      = errors.New("an unverified account uses the email address")
+)
+
+// oidcStateCookiePath scopes the state cookie to the provider's endpoints.
+func oidcStateCookiePath(provider string) string {
+	return "/api/auth/" + provider + "/"
+}
+
+// OIDCStartHandler sends the user to log in at an external identity
+// provider, which sends them back to OIDCCallbackHandler.
+func OIDCStartHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	name := r.PathValue("provider")
+	provider, ok := cfg.OIDCProviders[name]
+	if !ok {
+		respondWithError(w, http.StatusNotFound, fmt.Sprintf("Error: unknown identity provider %q", name), nil)
+		return
+	}
+
+	// state ties the callback to this browser, nonce the ID token to this
+	// login, and the code verifier the authorization code
+	state := auth.OIDCState{Provider: name}
+	for _, value := range []*string{&state.State, &state.Nonce, &state.CodeVerifier} {
+		var err error
+		*value, err = auth.MakeOAuthSecret()
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error starting login: %s", err), err)
+			return
+		}
+	}
+	stateToken, err := auth.MakeOIDCStateToken(state, cfg.Keys, oidcLoginLifetime)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error: state token creation error: %s", err), err)
+		return
+	}
+
+	authURL, err := provider.AuthCodeURL(r.Context(), state.State, state.Nonce, auth.PKCEChallenge(state.CodeVerifier))
+	if err != nil {
+		respondWithError(w, http.StatusBadGateway, "Error reaching the identity provider", err)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    stateToken,
+		Path:     oidcStateCookiePath(name),
+		MaxAge:   int(oidcLoginLifetime.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		// Lax, so the cookie comes along when the provider redirects back
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCCallbackHandler finishes a login at an external identity provider and
+// logs the user in like LoginHandler. Users are matched by the identity
+// linked to their account, then by verified email, and are signed up if
+// neither matches.
+func OIDCCallbackHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	name := r.PathValue("provider")
+	provider, ok := cfg.OIDCProviders[name]
+	if !ok {
+		respondWithError(w, http.StatusNotFound, fmt.Sprintf("Error: unknown identity provider %q", name), nil)
+		return
+	}
+
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error: no login in progress, it may have expired", err)
+		return
+	}
+	// each login can only be finished once
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Path:     oidcStateCookiePath(name),
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	state, err := auth.ValidateOIDCStateToken(cookie.Value, cfg.Keys)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error: no login in progress, it may have expired", err)
+		return
+	}
+	query := r.URL.Query()
+	if state.Provider != name || subtle.ConstantTimeCompare([]byte(state.State), []byte(query.Get("state"))) != 1 {
+		respondWithError(w, http.StatusBadRequest, "Error: state does not match the login in progress", nil)
+		return
+	}
+
+	if errorCode := query.Get("error"); errorCode != "" {
+		respondWithError(w, http.StatusUnauthorized, fmt.Sprintf("Error: login at %s failed: %s", name, errorCode), nil)
+		return
+	}
+	code := query.Get("code")
+	if code == "" {
+		respondWithError(w, http.StatusBadRequest, "Error: missing authorization code", nil)
+		return
+	}
+
+	identity, err := provider.Exchange(r.Context(), code, state.CodeVerifier, state.Nonce)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, fmt.Sprintf("Error: login at %s failed", name), err)
+		return
+	}
+
+	user, err := oidcUser(r, cfg, name, identity)
+	if errors.Is(err, errIdentityEmailUnverified) {
+		respondWithError(w, http.StatusForbidden, fmt.Sprintf("Error: %s", err), nil)
+		return
+	}
+	if errors.Is(err, errIdentityEmailTaken) || isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "Error: an account with this email already exists, log in with its password", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error looking for user: %s", err), err)
+		return
+	}
+
+	finishLogin(w, r, cfg, user)
+}
+
+// oidcUser returns the user identity is linked to. Unknown identities are
+// linked to the account with their email, which both sides must have
+// verified, or to a new account.
+func oidcUser(r *http.Request, cfg *types.ApiConfig, provider string, identity oidc.Identity) (database.User, error) {
+	linked, err := cfg.DbQueries.GetUserIdentity(r.Context(), database.GetUserIdentityParams{
+		Provider: provider,
+		Subject:  identity.Subject,
+	})
+	if err == nil {
+		err = cfg.DbQueries.TouchUserIdentity(r.Context(), database.TouchUserIdentityParams{
+			Provider: provider,
+			Subject:  identity.Subject,
+		})
+		if err != nil {
+			return database.User{}, err
+		}
+		return cfg.DbQueries.GetUserById(r.Context(), linked.UserID)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, err
+	}
+
+	if !identity.EmailVerified || !validEmail(identity.Email) {
+		return database.User{}, errIdentityEmailUnverified
+	}
+
+	// otherwise whoever registered the address first, without proving they
+	// own it, would get the account
+	user, err := cfg.DbQueries.GetUserByEmail(r.Context(), identity.Email)
+	if err == nil && !user.VerifiedAt.Valid {
+		return database.User{}, errIdentityEmailTaken
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		user, err = createOIDCUser(r, cfg, identity.Email)
+	}
+	if err != nil {
+		return database.User{}, err
+	}
+
+	_, err = cfg.DbQueries.CreateUserIdentity(r.Context(), database.CreateUserIdentityParams{
+		Provider: provider,
+		Subject:  identity.Subject,
+		UserID:   user.ID,
+		Email:    identity.Email,
+	})
+	if err != nil {
+		return database.User{}, err
+	}
+	return user, nil
+}
+
+// createOIDCUser signs up a user whose email the provider verified. They get
+// a random password, and can set their own with a password reset.
+func createOIDCUser(r *http.Request, cfg *types.ApiConfig, email string) (database.User, error) {
+	password, err := auth.MakeOAuthSecret()
+	if err != nil {
+		return database.User{}, err
+	}
+	hashedPassword, err := cfg.Passwords.Hash(password)
+	if err != nil {
+		return database.User{}, err
+	}
+
+	user, err := cfg.DbQueries.CreateUser(r.Context(), database.CreateUserParams{
+		Email:          email,
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		return database.User{}, err
+	}
+	return cfg.DbQueries.VerifyUserEmail(r.Context(), database.VerifyUserEmailParams{
+		ID:    user.ID,
+		Email: user.Email,
+	})
+}
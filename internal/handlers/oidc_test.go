@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kevinjimenez96/chirpy/internal/database"
+	"github.com/kevinjimenez96/chirpy/internal/oidc"
+	"github.com/kevinjimenez96/chirpy/internal/oidc/oidctest"
+	"github.com/kevinjimenez96/chirpy/internal/types"
+)
+
+// oidcTestServer is testServer with an identity provider named "test",
+// backed by a stand-in provider.
+func oidcTestServer(t *testing.T) (*httptest.Server, *types.ApiConfig, *oidctest.Provider) {
+	server, cfg := testServer(t)
+
+	idp := oidctest.NewProvider(t)
+	cfg.OIDCProviders = map[string]*oidc.Provider{
+		"test": oidc.NewProvider(oidc.Config{
+			Issuer:       idp.Issuer(),
+			ClientID:     oidctest.ClientID,
+			ClientSecret: oidctest.ClientSecret,
+			RedirectURL:  server.URL + "/api/auth/test/callback",
+		}),
+	}
+	return server, cfg, idp
+}
+
+// oidcLogin logs in at the provider the way a browser would, following the
+// redirects from the start endpoint to the callback.
+func oidcLogin(t *testing.T, server *httptest.Server) (int, types.LoginUserRes) {
+	t.Helper()
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{Jar: jar}
+	resp, err := client.Get(server.URL + "/api/auth/test/start")
+	if err != nil {
+		t.Fatalf("GET /api/auth/test/start error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	res := types.LoginUserRes{}
+	json.NewDecoder(resp.Body).Decode(&res)
+	return resp.StatusCode, res
+}
+
+func TestOIDCLogin(t *testing.T) {
+	server, cfg, idp := oidcTestServer(t)
+
+	idp.SetUser(oidctest.User{Subject: "1234", Email: "a@example.com", EmailVerified: true})
+	status, res := oidcLogin(t, server)
+	if status != http.StatusOK || res.Token == "" || res.RefreshToken == "" {
+		t.Fatalf("login = %d, %+v, want 200 with tokens", status, res)
+	}
+	if res.Email != "a@example.com" || !res.IsVerified {
+		t.Errorf("login signed up %+v, want a verified a@example.com", res)
+	}
+	me := types.LoginUserRes{}
+	if status := doJSON(t, http.MethodGet, server.URL+"/api/users/me", res.Token, nil, &me); status != http.StatusOK || me.ID != res.ID {
+		t.Errorf("GET /api/users/me = %d, %+v, want the new user", status, me)
+	}
+
+	// the identity stays linked when the email at the provider changes
+	idp.SetUser(oidctest.User{Subject: "1234", Email: "b@example.com", EmailVerified: true})
+	status, again := oidcLogin(t, server)
+	if status != http.StatusOK || again.ID != res.ID {
+		t.Errorf("second login = %d, %+v, want user %v", status, again, res.ID)
+	}
+
+	// a verified account with the same email is linked rather than duplicated
+	user, _ := cfg.DbQueries.CreateUser(context.Background(), database.CreateUserParams{Email: "c@example.com", HashedPassword: "hash"})
+	cfg.DbQueries.VerifyUserEmail(context.Background(), database.VerifyUserEmailParams{ID: user.ID, Email: user.Email})
+	idp.SetUser(oidctest.User{Subject: "5678", Email: "c@example.com", EmailVerified: true})
+	if status, res := oidcLogin(t, server); status != http.StatusOK || res.ID != user.ID {
+		t.Errorf("login with the email of a verified account = %d, %+v, want user %v", status, res, user.ID)
+	}
+	identity, err := cfg.DbQueries.GetUserIdentity(context.Background(), database.GetUserIdentityParams{Provider: "test", Subject: "5678"})
+	if err != nil || identity.UserID != user.ID {
+		t.Errorf("GetUserIdentity() = %+v, %v, want an identity of %v", identity, err, user.ID)
+	}
+}
+
+func TestOIDCLoginRejected(t *testing.T) {
+	server, cfg, idp := oidcTestServer(t)
+
+	idp.SetUser(oidctest.User{Subject: "1", Email: "a@example.com", EmailVerified: false})
+	if status, _ := oidcLogin(t, server); status != http.StatusForbidden {
+		t.Errorf("login with an unverified email = %d, want 403", status)
+	}
+
+	// nobody gets an account whose owner never proved the address
+	cfg.DbQueries.CreateUser(context.Background(), database.CreateUserParams{Email: "b@example.com", HashedPassword: "hash"})
+	idp.SetUser(oidctest.User{Subject: "2", Email: "b@example.com", EmailVerified: true})
+	if status, _ := oidcLogin(t, server); status != http.StatusConflict {
+		t.Errorf("login with the email of an unverified account = %d, want 409", status)
+	}
+
+	if status := doJSON(t, http.MethodGet, server.URL+"/api/auth/other/start", "", nil, nil); status != http.StatusNotFound {
+		t.Errorf("GET /api/auth/other/start = %d, want 404", status)
+	}
+
+	// callbacks only finish a login started in the same browser
+	if status := doJSON(t, http.MethodGet, server.URL+"/api/auth/test/callback?code=x&state=y", "", nil, nil); status != http.StatusBadRequest {
+		t.Errorf("callback without a login in progress = %d, want 400", status)
+	}
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{
+		Jar:           jar,
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	resp, err := client.Get(server.URL + "/api/auth/test/start")
+	if err != nil || resp.StatusCode != http.StatusFound {
+		t.Fatalf("GET /api/auth/test/start = %v, %v, want a redirect", resp, err)
+	}
+	resp.Body.Close()
+	resp, err = client.Get(server.URL + "/api/auth/test/callback?code=x&state=forged")
+	if err != nil || resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("callback with another state = %v, %v, want 400", resp, err)
+	}
+	resp.Body.Close()
+}
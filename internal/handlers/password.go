@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/kevinjimenez96/chirpy/internal/auth"
+	"github.com/kevinjimenez96/chirpy/internal/database"
+	"github.com/kevinjimenez96/chirpy/internal/mailer"
+	"github.com/kevinjimenez96/chirpy/internal/types"
+)
+
+const passwordResetEmailBody = `Someone asked to reset the password of your Chirpy account.
+
+If it was you, send this token with your new password to POST /api/password/reset:
+
+%s
+
+The token expires in 30 minutes and works once. If you didn't ask for a reset,
+you can ignore this email.
+`
+
+// checkPasswordPolicy reports whether password is acceptable. If it isn't,
+// it responds with a 400 listing every rule that failed.
+func checkPasswordPolicy(w http.ResponseWriter, cfg *types.ApiConfig, password string) bool {
+	violations := cfg.PasswordPolicy.Check(password)
+	if len(violations) == 0 {
+		return true
+	}
+	respondWithJSON(w, http.StatusBadRequest, types.PasswordPolicyErrorRes{
+		Error:      "Password does not meet the password policy",
+		Violations: violations,
+	})
+	return false
+}
+
+// ForgotPasswordHandler emails a reset token to the owner of an address. It
+// answers the same way whether or not the address belongs to an account, so
+// it can't be used to find out who is registered: the email is sent in the
+// background, so the response doesn't take longer when there is one to send.
+func ForgotPasswordHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	decoder := json.NewDecoder(r.Body)
+	forgotPasswordReq := types.ForgotPasswordReq{}
+	err := decoder.Decode(&forgotPasswordReq)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Error decoding forgot password request: %s", err), err)
+		return
+	}
+
+	user, err := cfg.DbQueries.GetUserByEmail(r.Context(), forgotPasswordReq.Email)
+	if errors.Is(err, sql.ErrNoRows) || err == nil && user.DeletedAt.Valid {
+		respondWithJSON(w, http.StatusAccepted, nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error looking for user: %s", err), err)
+		return
+	}
+
+	// the email is sent after responding, so it must not be canceled with the
+	// request
+	ctx := context.WithoutCancel(r.Context())
+	go func() {
+		if err := sendPasswordResetEmail(ctx, cfg, user); err != nil {
+			log.Printf("Error sending password reset email to user %s: %s", user.ID, err)
+		}
+	}()
+
+	respondWithJSON(w, http.StatusAccepted, nil)
+}
+
+func sendPasswordResetEmail(ctx context.Context, cfg *types.ApiConfig, user database.User) error {
+	token, err := auth.MakePasswordResetToken()
+	if err != nil {
+		return err
+	}
+
+	_, err = cfg.DbQueries.CreatePasswordResetToken(ctx, database.CreatePasswordResetTokenParams{
+		TokenHash: auth.HashPasswordResetToken(token),
+		UserID:    user.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	return cfg.Mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your Chirpy password",
+		Body:    fmt.Sprintf(passwordResetEmailBody, token),
+	})
+}
+
+// ResetPasswordHandler sets a new password using an emailed reset token and
+// signs the user out everywhere.
+func ResetPasswordHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	decoder := json.NewDecoder(r.Body)
+	resetPasswordReq := types.ResetPasswordReq{}
+	err := decoder.Decode(&resetPasswordReq)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Error decoding reset password request: %s", err), err)
+		return
+	}
+
+	if !checkPasswordPolicy(w, cfg, resetPasswordReq.Password) {
+		return
+	}
+
+	// marking the token used in the same statement makes it single-use even
+	// under concurrent requests
+	resetToken, err := cfg.DbQueries.UsePasswordResetToken(r.Context(), auth.HashPasswordResetToken(resetPasswordReq.Token))
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusUnauthorized, "Invalid or expired reset token", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error looking for reset token: %s", err), err)
+		return
+	}
+
+	hashedPassword, err := cfg.Passwords.Hash(resetPasswordReq.Password)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error resetting password: %s", err), err)
+		return
+	}
+
+	_, err = cfg.DbQueries.UpdateUserPassword(r.Context(), database.UpdateUserPasswordParams{
+		ID:             resetToken.UserID,
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error resetting password: %s", err), err)
+		return
+	}
+
+	err = cfg.DbQueries.InvalidatePasswordResetTokensForUser(r.Context(), resetToken.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error invalidating reset tokens: %s", err), err)
+		return
+	}
+
+	err = revokeAllSessions(r, cfg, resetToken.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error revoking sessions: %s", err), err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
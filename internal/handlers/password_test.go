@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinjimenez96/chirpy/internal/mailer"
+	"github.com/kevinjimenez96/chirpy/internal/types"
+)
+
+// waitForMail returns the messages sent through m once there are n of them.
+// Some emails are sent after responding.
+func waitForMail(t *testing.T, m *testMailer, n int) []mailer.Message {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		messages := m.sent()
+		if len(messages) >= n || time.Now().After(deadline) {
+			return messages
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestPasswordReset(t *testing.T) {
+	server, cfg := testServer(t)
+	createTestUser(t, cfg, "a@example.com", "password1")
+	session := login(t, server, "a@example.com", "password1")
+	sentMail := cfg.Mailer.(*testMailer)
+
+	if status := doJSON(t, http.MethodPost, server.URL+"/api/password/forgot", "", types.ForgotPasswordReq{Email: "nobody@example.com"}, nil); status != http.StatusAccepted {
+		t.Errorf("POST /api/password/forgot for an unknown email = %d, want 202", status)
+	}
+	if status := doJSON(t, http.MethodPost, server.URL+"/api/password/forgot", "", types.ForgotPasswordReq{Email: "a@example.com"}, nil); status != http.StatusAccepted {
+		t.Fatalf("POST /api/password/forgot = %d, want 202", status)
+	}
+	messages := waitForMail(t, sentMail, 1)
+	if len(messages) != 1 || messages[0].To != "a@example.com" {
+		t.Fatalf("sent %+v, want one email to a@example.com", messages)
+	}
+	words := strings.Fields(messages[0].Body)
+	i := slices.Index(words, "/api/password/reset:")
+	if i < 0 || i+1 >= len(words) {
+		t.Fatalf("email body = %q, want a reset token", messages[0].Body)
+	}
+	token := words[i+1]
+
+	reset := types.ResetPasswordReq{Token: token, Password: "password2"}
+	if status := doJSON(t, http.MethodPost, server.URL+"/api/password/reset", "", reset, nil); status != http.StatusNoContent {
+		t.Fatalf("POST /api/password/reset = %d, want 204", status)
+	}
+	if status := doJSON(t, http.MethodPost, server.URL+"/api/password/reset", "", reset, nil); status != http.StatusUnauthorized {
+		t.Errorf("POST /api/password/reset with a used token = %d, want 401", status)
+	}
+	if status := doJSON(t, http.MethodPost, server.URL+"/api/refresh", session.RefreshToken, nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("POST /api/refresh after a reset = %d, want 401", status)
+	}
+	login(t, server, "a@example.com", "password2")
+}
@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/kevinjimenez96/chirpy/internal/auth"
+)
+
+// requirePrincipal returns the caller stored by MiddlewareAuth. If there is
+// none, which means the route is missing the middleware, it responds with a
+// 401 so the handler never acts on behalf of uuid.Nil.
+func requirePrincipal(w http.ResponseWriter, r *http.Request) (auth.Principal, bool) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, "Error: not authenticated", nil)
+		return auth.Principal{}, false
+	}
+	return principal, true
+}
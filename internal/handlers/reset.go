@@ -9,11 +9,13 @@ import (
 func ResetHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
 	if cfg.Platform != "dev" {
 		respondWithError(w, http.StatusForbidden, "Forbidden", nil)
+		return
 	}
 	err := cfg.DbQueries.DeleteAllUsers(r.Context())
 
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Server error: could not reset users", err)
+		return
 	}
 	cfg.FileserverHits.Store(0)
 	w.WriteHeader(http.StatusOK)
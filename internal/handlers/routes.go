@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/kevinjimenez96/chirpy/internal/auth"
+	"github.com/kevinjimenez96/chirpy/internal/types"
+)
+
+// Register adds the routes of the API to mux. Static files under /app/ are
+// left to the caller.
+func Register(mux *http.ServeMux, cfg *types.ApiConfig) {
+	mux.Handle("GET /admin/metrics", cfg.MiddlewareRequireRole(auth.RoleAdmin, cfg.MiddlewareAddConfig(MetricsHandler)))
+	mux.Handle("POST /admin/reset", cfg.MiddlewareRequireRole(auth.RoleAdmin, cfg.MiddlewareAddConfig(ResetHandler)))
+	mux.Handle("POST /admin/users/{id}/ban", cfg.MiddlewareRequireRole(auth.RoleAdmin, cfg.MiddlewareAddConfig(BanUserHandler)))
+	mux.Handle("DELETE /admin/users/{id}/ban", cfg.MiddlewareRequireRole(auth.RoleAdmin, cfg.MiddlewareAddConfig(UnbanUserHandler)))
+	mux.Handle("PUT /admin/users/{id}/role", cfg.MiddlewareRequireRole(auth.RoleAdmin, cfg.MiddlewareAddConfig(UpdateUserRoleHandler)))
+	mux.HandleFunc("GET /api/healthz", HealthzHandler)
+	mux.Handle("GET /.well-known/jwks.json", cfg.MiddlewareAddConfig(JWKSHandler))
+
+	mux.Handle("GET /admin/moderation/words", cfg.MiddlewareRequireRole(auth.RoleAdmin, cfg.MiddlewareAddConfig(ListBannedWordsHandler)))
+	mux.Handle("POST /admin/moderation/words", cfg.MiddlewareRequireRole(auth.RoleAdmin, cfg.MiddlewareAddConfig(AddBannedWordHandler)))
+	mux.Handle("DELETE /admin/moderation/words/{word}", cfg.MiddlewareRequireRole(auth.RoleAdmin, cfg.MiddlewareAddConfig(DeleteBannedWordHandler)))
+	mux.Handle("GET /admin/moderation/words/changes", cfg.MiddlewareRequireRole(auth.RoleAdmin, cfg.MiddlewareAddConfig(ListBannedWordChangesHandler)))
+
+	mux.Handle("GET /api/chirps", cfg.MiddlewareOptionalAuth(auth.ScopeChirpsRead, cfg.MiddlewareAddConfig(GetAllChirps)))
+	mux.Handle("GET /api/chirps/search", cfg.MiddlewareAddConfig(SearchChirps))
+	mux.Handle("GET /api/chirps/{id}", cfg.MiddlewareOptionalAuth(auth.ScopeChirpsRead, cfg.MiddlewareAddConfig(GetChirpById)))
+	mux.Handle("POST /api/chirps", cfg.MiddlewareAuth(auth.ScopeChirpsWrite, cfg.MiddlewareAddConfig(AddChirp)))
+	mux.Handle("DELETE /api/chirps/{id}", cfg.MiddlewareAuth(auth.ScopeChirpsWrite, cfg.MiddlewareAddConfig(DeleteChirpByIdHandler)))
+	mux.Handle("GET /api/chirps/{id}/thread", cfg.MiddlewareOptionalAuth(auth.ScopeChirpsRead, cfg.MiddlewareAddConfig(GetChirpThread)))
+	mux.Handle("POST /api/chirps/{id}/like", cfg.MiddlewareAuth(auth.ScopeChirpsWrite, cfg.MiddlewareAddConfig(LikeChirpHandler)))
+	mux.Handle("DELETE /api/chirps/{id}/like", cfg.MiddlewareAuth(auth.ScopeChirpsWrite, cfg.MiddlewareAddConfig(UnlikeChirpHandler)))
+
+	mux.Handle("POST /api/users", cfg.MiddlewareAddConfig(AddUserHandler))
+	mux.Handle("PUT /api/users", cfg.MiddlewareAuth(auth.ScopeAccount, cfg.MiddlewareAddConfig(UpdateUserHandler)))
+	mux.Handle("PATCH /api/users", cfg.MiddlewareAuth(auth.ScopeAccount, cfg.MiddlewareAddConfig(UpdateUserHandler)))
+	mux.Handle("GET /api/users/me", cfg.MiddlewareAuth(auth.ScopeProfileRead, cfg.MiddlewareAddConfig(GetCurrentUserHandler)))
+	mux.Handle("DELETE /api/users/me", cfg.MiddlewareAuth(auth.ScopeAccount, cfg.MiddlewareAddConfig(DeleteAccountHandler)))
+	mux.Handle("POST /api/users/me/restore", cfg.MiddlewareAddConfig(RestoreAccountHandler))
+	mux.Handle("POST /api/users/me/2fa", cfg.MiddlewareAuth(auth.ScopeAccount, cfg.MiddlewareAddConfig(EnrollTOTPHandler)))
+	mux.Handle("POST /api/users/me/2fa/confirm", cfg.MiddlewareAuth(auth.ScopeAccount, cfg.MiddlewareAddConfig(ConfirmTOTPHandler)))
+	mux.Handle("GET /api/users/me/api-keys", cfg.MiddlewareAuth(auth.ScopeAccount, cfg.MiddlewareAddConfig(ListAPIKeysHandler)))
+	mux.Handle("POST /api/users/me/api-keys", cfg.MiddlewareAuth(auth.ScopeAccount, cfg.MiddlewareAddConfig(CreateAPIKeyHandler)))
+	mux.Handle("DELETE /api/users/me/api-keys/{id}", cfg.MiddlewareAuth(auth.ScopeAccount, cfg.MiddlewareAddConfig(RevokeAPIKeyHandler)))
+	mux.Handle("POST /api/users/verify", cfg.MiddlewareAddConfig(VerifyEmailHandler))
+	mux.Handle("POST /api/users/verify/resend", cfg.MiddlewareAuth(auth.ScopeAccount, cfg.MiddlewareAddConfig(ResendVerificationEmailHandler)))
+
+	mux.Handle("POST /api/password/forgot", cfg.MiddlewareAddConfig(ForgotPasswordHandler))
+	mux.Handle("POST /api/password/reset", cfg.MiddlewareAddConfig(ResetPasswordHandler))
+
+	mux.Handle("POST /api/login", cfg.MiddlewareAddConfig(LoginHandler))
+	mux.Handle("POST /api/login/2fa", cfg.MiddlewareAddConfig(LoginTwoFactorHandler))
+	mux.Handle("POST /api/refresh", cfg.MiddlewareAddConfig(RefreshTokenHandler))
+	mux.Handle("POST /api/revoke", cfg.MiddlewareAddConfig(RevokeHandler))
+	mux.Handle("GET /api/auth/{provider}/start", cfg.MiddlewareAddConfig(OIDCStartHandler))
+	mux.Handle("GET /api/auth/{provider}/callback", cfg.MiddlewareAddConfig(OIDCCallbackHandler))
+
+	mux.Handle("GET /api/sessions", cfg.MiddlewareAuth(auth.ScopeAccount, cfg.MiddlewareAddConfig(ListSessionsHandler)))
+	mux.Handle("DELETE /api/sessions", cfg.MiddlewareAuth(auth.ScopeAccount, cfg.MiddlewareAddConfig(RevokeAllSessionsHandler)))
+	mux.Handle("DELETE /api/sessions/{id}", cfg.MiddlewareAuth(auth.ScopeAccount, cfg.MiddlewareAddConfig(RevokeSessionHandler)))
+
+	mux.Handle("GET /api/oauth/clients", cfg.MiddlewareAuth(auth.ScopeAccount, cfg.MiddlewareAddConfig(ListOAuthClientsHandler)))
+	mux.Handle("POST /api/oauth/clients", cfg.MiddlewareAuth(auth.ScopeAccount, cfg.MiddlewareAddConfig(CreateOAuthClientHandler)))
+	mux.Handle("DELETE /api/oauth/clients/{id}", cfg.MiddlewareAuth(auth.ScopeAccount, cfg.MiddlewareAddConfig(DeleteOAuthClientHandler)))
+	mux.Handle("GET /api/oauth/authorize", cfg.MiddlewareAuth(auth.ScopeAccount, cfg.MiddlewareAddConfig(OAuthAuthorizeHandler)))
+	mux.Handle("POST /api/oauth/authorize", cfg.MiddlewareAuth(auth.ScopeAccount, cfg.MiddlewareAddConfig(OAuthConsentHandler)))
+	mux.Handle("POST /api/oauth/token", cfg.MiddlewareAddConfig(OAuthTokenHandler))
+	mux.Handle("POST /api/oauth/introspect", cfg.MiddlewareAddConfig(OAuthIntrospectHandler))
+
+	mux.Handle("POST /api/polka/webhooks", cfg.MiddlewareAddConfig(PolkaWebHook))
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/kevinjimenez96/chirpy/internal/database"
+	"github.com/kevinjimenez96/chirpy/internal/types"
+)
+
+func SearchChirps(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	q := r.URL.Query().Get("q")
+	authorId := r.URL.Query().Get("author_id")
+
+	query := buildTsQuery(q)
+	if query == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing search query: q", nil)
+		return
+	}
+
+	limit, ok := readChirpsLimit(w, r)
+	if !ok {
+		return
+	}
+
+	var authorIdUUID uuid.NullUUID
+	if authorId != "" {
+		parsedAuthorId, err := uuid.Parse(authorId)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid author_id: %s", err), err)
+			return
+		}
+		authorIdUUID = uuid.NullUUID{UUID: parsedAuthorId, Valid: true}
+	}
+
+	chirps, err := cfg.DbQueries.SearchChirps(r.Context(), database.SearchChirpsParams{
+		Query:    query,
+		AuthorID: authorIdUUID,
+		RowLimit: int32(limit),
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error searching chirps: %s", err), err)
+		return
+	}
+
+	if chirps == nil {
+		chirps = []database.SearchChirpsRow{}
+	}
+
+	respondWithJSON(w, http.StatusOK, chirps)
+}
+
+// buildTsQuery turns a search string into a to_tsquery expression. Terms are
+// ANDed together, "quoted phrases" must match in order and a trailing * makes
+// a prefix match. Anything other than letters, digits and the marks combined
+// with them is dropped, so user input can't break out of the tsquery syntax.
+func buildTsQuery(q string) string {
+	var clauses []string
+
+	addClause := func(text string) {
+		lexemes := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r)
+		})
+		if len(lexemes) == 0 {
+			return
+		}
+		if strings.HasSuffix(text, "*") {
+			lexemes[len(lexemes)-1] += ":*"
+		}
+		if len(lexemes) == 1 {
+			clauses = append(clauses, lexemes[0])
+			return
+		}
+		clauses = append(clauses, "("+strings.Join(lexemes, " <-> ")+")")
+	}
+
+	for rest := strings.TrimSpace(q); rest != ""; rest = strings.TrimSpace(rest) {
+		if phrase, found := strings.CutPrefix(rest, `"`); found {
+			phrase, rest, _ = strings.Cut(phrase, `"`)
+			// allow "some phrase"* to prefix-match the last word
+			if after, isPrefix := strings.CutPrefix(rest, "*"); isPrefix {
+				phrase, rest = phrase+"*", after
+			}
+			addClause(phrase)
+			continue
+		}
+
+		term := rest
+		if i := strings.IndexFunc(rest, unicode.IsSpace); i >= 0 {
+			term, rest = rest[:i], rest[i:]
+		} else {
+			rest = ""
+		}
+		addClause(term)
+	}
+
+	return strings.Join(clauses, " & ")
+}
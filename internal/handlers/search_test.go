@@ -0,0 +1,43 @@
+package handlers
+
+import "testing"
+
+func TestBuildTsQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		q    string
+		want string
+	}{
+		{name: "Terms", q: "hello world", want: "hello & world"},
+		{name: "Empty", q: "", want: ""},
+		{name: "Whitespace only", q: " \t\n ", want: ""},
+		{name: "Other whitespace", q: "tab\tsep\nnl", want: "tab & sep & nl"},
+		{name: "Punctuation", q: "hello, world!", want: "hello & world"},
+		{name: "Punctuation only", q: "!!! ???", want: ""},
+		{name: "Operators", q: "a & b | !c", want: "a & b & c"},
+		{name: "Parentheses", q: "(a|b)", want: "(a <-> b)"},
+		{name: "Weights", q: "bar:A", want: "(bar <-> a)"},
+		{name: "Prefix", q: "chirp*", want: "chirp:*"},
+		{name: "Prefix after a colon", q: "foo:*", want: "foo:*"},
+		{name: "Stars only", q: "***", want: ""},
+		{name: "Star inside a term", q: "a*b", want: "(a <-> b)"},
+		{name: "Single quotes", q: "'quoted' x", want: "quoted & x"},
+		{name: "Apostrophe", q: "O'Reilly", want: "(o <-> reilly)"},
+		{name: "Phrase", q: `"big brown" dog`, want: "(big <-> brown) & dog"},
+		{name: "Prefix phrase", q: `"big brown"* fox`, want: "(big <-> brown:*) & fox"},
+		{name: "Unclosed phrase", q: `"unclosed phrase`, want: "(unclosed <-> phrase)"},
+		{name: "Empty phrase", q: `"" *`, want: ""},
+		{name: "Accents", q: "Café naïve", want: "café & naïve"},
+		{name: "Combining marks", q: "cafe\u0301 हिन्दी", want: "cafe\u0301 & हिन्दी"},
+		{name: "Other scripts", q: "日本語 テスト", want: "日本語 & テスト"},
+		{name: "Unicode prefix", q: "Ünïcödé*", want: "ünïcödé:*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildTsQuery(tt.q); got != tt.want {
+				t.Errorf("buildTsQuery(%q) = %q, want %q", tt.q, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kevinjimenez96/chirpy/internal/auth"
+	"github.com/kevinjimenez96/chirpy/internal/database"
+	"github.com/kevinjimenez96/chirpy/internal/mailer"
+	"github.com/kevinjimenez96/chirpy/internal/moderation"
+	"github.com/kevinjimenez96/chirpy/internal/types"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testAdminKey is the admin API key of testServer.
+const testAdminKey = "admin-key"
+
+// testThrottlePolicy lets an account fail three logins before it has to
+// wait, long enough for a test to see the 429.
+var testThrottlePolicy = auth.ThrottlePolicy{
+	FreeAttempts: 3,
+	BaseDelay:    time.Minute,
+	MaxDelay:     time.Hour,
+	LockoutAfter: 10,
+	Lockout:      time.Hour,
+}
+
+// testMailer keeps the messages sent through it.
+type testMailer struct {
+	mu       sync.Mutex
+	messages []mailer.Message
+}
+
+func (m *testMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+func (m *testMailer) sent() []mailer.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]mailer.Message(nil), m.messages...)
+}
+
+// testServer serves the API routes main registers, backed by a fresh
+// MemoryStore and moderated like main, admin-managed banned words included.
+// Passwords are hashed with the cheapest bcrypt cost to keep tests fast, and
+// every test client shares the loopback address, so only accounts are
+// throttled.
+func testServer(t *testing.T) (*httptest.Server, *types.ApiConfig) {
+	store := database.NewMemoryStore()
+	moderator := append(moderation.DefaultChain(), &moderation.WordListRule{
+		Name:   "banned-words",
+		Source: moderation.StoreWords{Store: store},
+		Action: moderation.ActionMask,
+	})
+	cfg := &types.ApiConfig{
+		DbQueries: store,
+		Keys:      auth.NewHMACKeySet("secret"),
+		Denylist:  auth.NewDenylist(store, time.Minute),
+		AdminKey:  testAdminKey,
+		Moderator: moderator,
+		Mailer:    &testMailer{},
+
+		PasswordPolicy:      auth.DefaultPasswordPolicy(),
+		Passwords:           &auth.PasswordHasher{Scheme: auth.SchemeBcrypt, BcryptCost: bcrypt.MinCost},
+		AccountThrottle:     auth.NewThrottle(testThrottlePolicy),
+		IPThrottle:          auth.NewThrottle(auth.ThrottlePolicy{}),
+		DeletionGracePeriod: time.Hour,
+	}
+
+	mux := http.NewServeMux()
+	Register(mux, cfg)
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, cfg
+}
+
+func doJSON(t *testing.T, method, target, token string, body, res any) int {
+	t.Helper()
+	authorization := ""
+	if token != "" {
+		authorization = "Bearer " + token
+	}
+	return doAuthorized(t, method, target, authorization, body, res)
+}
+
+// doAuthorized is doJSON for credentials other than access tokens, such as
+// "ApiKey <key>".
+func doAuthorized(t *testing.T, method, target, authorization string, body, res any) int {
+	t.Helper()
+	var reqBody bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&reqBody).Encode(body)
+	}
+	req, _ := http.NewRequest(method, target, &reqBody)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s error = %v", method, target, err)
+	}
+	defer resp.Body.Close()
+	if res != nil {
+		json.NewDecoder(resp.Body).Decode(res)
+	}
+	return resp.StatusCode
+}
+
+// createTestUser signs up a verified user with password and returns them
+// with an access token.
+func createTestUser(t *testing.T, cfg *types.ApiConfig, email, password string) (database.User, string) {
+	t.Helper()
+	ctx := context.Background()
+	hashedPassword, err := cfg.Passwords.Hash(password)
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	user, err := cfg.DbQueries.CreateUser(ctx, database.CreateUserParams{Email: email, HashedPassword: hashedPassword})
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	user, err = cfg.DbQueries.VerifyUserEmail(ctx, database.VerifyUserEmailParams{ID: user.ID, Email: user.Email})
+	if err != nil {
+		t.Fatalf("VerifyUserEmail() error = %v", err)
+	}
+	token, err := auth.MakeJWT(user.ID, auth.RoleUser, cfg.Keys, time.Hour)
+	if err != nil {
+		t.Fatalf("MakeJWT() error = %v", err)
+	}
+	return user, token
+}
+
+// failingTxStore hands the transactions of a Store a Querier made by wrap,
+// which can make some queries fail to test that nothing is half written.
+type failingTxStore struct {
+	database.Store
+	wrap func(database.Querier) database.Querier
+}
+
+func (s failingTxStore) InTx(ctx context.Context, fn func(database.Querier) error) error {
+	return s.Store.InTx(ctx, func(q database.Querier) error {
+		return fn(s.wrap(q))
+	})
+}
+
+// login signs in through POST /api/login and returns the tokens of the new
+// session.
+func login(t *testing.T, server *httptest.Server, email, password string) types.LoginUserRes {
+	t.Helper()
+	res := types.LoginUserRes{}
+	if status := doJSON(t, http.MethodPost, server.URL+"/api/login", "", types.LoginUserReq{Email: email, Password: password}, &res); status != http.StatusOK {
+		t.Fatalf("POST /api/login = %d, want 200", status)
+	}
+	return res
+}
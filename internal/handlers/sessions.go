@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/kevinjimenez96/chirpy/internal/database"
+	"github.com/kevinjimenez96/chirpy/internal/types"
+)
+
+// maxUserAgentLength caps the user agent stored with a session.
+const maxUserAgentLength = 512
+
+// clientIP returns the address of the peer that sent the request.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// userAgent returns the user agent of a request, cut to maxUserAgentLength
+// bytes without splitting a character.
+func userAgent(r *http.Request) string {
+	ua := r.UserAgent()
+	if len(ua) <= maxUserAgentLength {
+		return ua
+	}
+	n := maxUserAgentLength
+	for n > 0 && !utf8.RuneStart(ua[n]) {
+		n--
+	}
+	return ua[:n]
+}
+
+// revokeSession revokes every refresh token of a session along with the
+// access tokens issued with them.
+func revokeSession(r *http.Request, cfg *types.ApiConfig, familyID uuid.UUID) error {
+	err := cfg.DbQueries.RevokeRefreshTokenFamily(r.Context(), familyID)
+	if err != nil {
+		return err
+	}
+
+	revokedTokens, err := cfg.DbQueries.RevokeAccessTokensForFamily(r.Context(), familyID)
+	if err != nil {
+		return err
+	}
+	denyAccessTokens(cfg, revokedTokens)
+	return nil
+}
+
+// revokeAllSessions signs a user out everywhere, like revokeSession does for
+// a single session.
+func revokeAllSessions(r *http.Request, cfg *types.ApiConfig, userID uuid.UUID) error {
+	err := cfg.DbQueries.RevokeAllRefreshTokensForUser(r.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	revokedTokens, err := cfg.DbQueries.RevokeAccessTokensForUser(r.Context(), userID)
+	if err != nil {
+		return err
+	}
+	denyAccessTokens(cfg, revokedTokens)
+	return nil
+}
+
+// denyAccessTokens makes revocations take effect on this instance right
+// away instead of when the denylist cache entries expire.
+func denyAccessTokens(cfg *types.ApiConfig, revokedTokens []database.RevokedAccessToken) {
+	for _, revokedToken := range revokedTokens {
+		cfg.Denylist.Revoke(revokedToken.ID, revokedToken.ExpiresAt)
+	}
+}
+
+func ListSessionsHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	refreshTokens, err := cfg.DbQueries.ListActiveRefreshTokensByUser(r.Context(), principal.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error listing sessions: %s", err), err)
+		return
+	}
+
+	sessions := make([]types.SessionRes, 0, len(refreshTokens))
+	for _, refreshToken := range refreshTokens {
+		session := types.SessionRes{
+			ID:        refreshToken.ID,
+			CreatedAt: refreshToken.CreatedAt,
+			ExpiresAt: refreshToken.ExpiresAt,
+			UserAgent: refreshToken.UserAgent,
+			IPAddress: refreshToken.IpAddress,
+		}
+		if refreshToken.LastUsedAt.Valid {
+			session.LastUsedAt = &refreshToken.LastUsedAt.Time
+		}
+		if refreshToken.ClientID.Valid {
+			session.ClientID = &refreshToken.ClientID.UUID
+		}
+		sessions = append(sessions, session)
+	}
+
+	respondWithJSON(w, http.StatusOK, sessions)
+}
+
+func RevokeSessionHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid session id: %s", err), err)
+		return
+	}
+
+	refreshToken, err := cfg.DbQueries.RevokeRefreshTokenByIdForUser(r.Context(), database.RevokeRefreshTokenByIdForUserParams{
+		ID:     id,
+		UserID: principal.UserID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Session not found", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error revoking session: %s", err), err)
+		return
+	}
+
+	err = revokeSession(r, cfg, refreshToken.FamilyID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error revoking session: %s", err), err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func RevokeAllSessionsHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	err := revokeAllSessions(r, cfg, principal.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error revoking sessions: %s", err), err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
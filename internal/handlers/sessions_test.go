@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/kevinjimenez96/chirpy/internal/types"
+)
+
+func TestUserAgent(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("User-Agent", "a"+strings.Repeat("é", maxUserAgentLength))
+	ua := userAgent(r)
+	if len(ua) > maxUserAgentLength || !utf8.ValidString(ua) {
+		t.Errorf("userAgent() = %d bytes, valid %v, want at most %d valid bytes", len(ua), utf8.ValidString(ua), maxUserAgentLength)
+	}
+	if len(ua) < maxUserAgentLength-1 {
+		t.Errorf("userAgent() = %d bytes, want only the split character cut", len(ua))
+	}
+}
+
+func TestSessions(t *testing.T) {
+	server, cfg := testServer(t)
+	_, token := createTestUser(t, cfg, "a@example.com", "password1")
+	_, otherToken := createTestUser(t, cfg, "b@example.com", "password1")
+	first := login(t, server, "a@example.com", "password1")
+	second := login(t, server, "a@example.com", "password1")
+	other := login(t, server, "b@example.com", "password1")
+
+	sessions := []types.SessionRes{}
+	if status := doJSON(t, http.MethodGet, server.URL+"/api/sessions", token, nil, &sessions); status != http.StatusOK || len(sessions) != 2 {
+		t.Fatalf("GET /api/sessions = %d, %+v, want both sessions", status, sessions)
+	}
+	if sessions[0].IPAddress != "127.0.0.1" {
+		t.Errorf("ip_address = %q, want 127.0.0.1", sessions[0].IPAddress)
+	}
+
+	otherSessions := []types.SessionRes{}
+	doJSON(t, http.MethodGet, server.URL+"/api/sessions", otherToken, nil, &otherSessions)
+	if len(otherSessions) != 1 {
+		t.Fatalf("GET /api/sessions of b = %+v, want one session", otherSessions)
+	}
+
+	// sessions of other users can't be revoked, or told apart from unknown ones
+	if status := doJSON(t, http.MethodDelete, server.URL+"/api/sessions/"+otherSessions[0].ID.String(), token, nil, nil); status != http.StatusNotFound {
+		t.Errorf("DELETE a session of b = %d, want 404", status)
+	}
+	if status := doJSON(t, http.MethodPost, server.URL+"/api/refresh", other.RefreshToken, nil, nil); status != http.StatusOK {
+		t.Errorf("POST /api/refresh of b = %d, want 200", status)
+	}
+
+	revoked := sessions[0]
+	if status := doJSON(t, http.MethodDelete, server.URL+"/api/sessions/"+revoked.ID.String(), token, nil, nil); status != http.StatusNoContent {
+		t.Fatalf("DELETE /api/sessions/{id} = %d, want 204", status)
+	}
+	sessions = []types.SessionRes{}
+	doJSON(t, http.MethodGet, server.URL+"/api/sessions", token, nil, &sessions)
+	if len(sessions) != 1 || sessions[0].ID == revoked.ID {
+		t.Errorf("GET /api/sessions = %+v, want only the session left", sessions)
+	}
+
+	if status := doJSON(t, http.MethodDelete, server.URL+"/api/sessions", token, nil, nil); status != http.StatusNoContent {
+		t.Fatalf("DELETE /api/sessions = %d, want 204", status)
+	}
+	for _, session := range []types.LoginUserRes{first, second} {
+		if status := doJSON(t, http.MethodPost, server.URL+"/api/refresh", session.RefreshToken, nil, nil); status != http.StatusUnauthorized {
+			t.Errorf("POST /api/refresh after signing out everywhere = %d, want 401", status)
+		}
+		if status := doJSON(t, http.MethodGet, server.URL+"/api/users/me", session.Token, nil, nil); status != http.StatusUnauthorized {
+			t.Errorf("GET /api/users/me after signing out everywhere = %d, want 401", status)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/kevinjimenez96/chirpy/internal/database"
+	"github.com/kevinjimenez96/chirpy/internal/types"
+)
+
+// GetChirpThread responds with the conversation around a chirp: every chirp
+// it replies to, and a page of the replies under it, nested ones included.
+// Deleted chirps in the thread are kept without their body.
+func GetChirpThread(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid chirp id: %s", err), err)
+		return
+	}
+
+	limit, cursor, ok := readChirpsPage(w, r)
+	if !ok {
+		return
+	}
+
+	chirp, err := cfg.DbQueries.GetChirpById(r.Context(), id)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, fmt.Sprintf("Error getting chirp: %s", err), err)
+		return
+	}
+
+	ancestors, err := cfg.DbQueries.GetChirpAncestors(r.Context(), id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error getting thread: %s", err), err)
+		return
+	}
+
+	// one extra row tells us whether there is a next page
+	params := database.GetChirpDescendantsParams{ID: id, RowLimit: int32(limit + 1)}
+	if cursor != nil {
+		params.CursorCreatedAt = sql.NullTime{Time: cursor.CreatedAt, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+	replies, err := cfg.DbQueries.GetChirpDescendants(r.Context(), params)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error getting thread: %s", err), err)
+		return
+	}
+
+	thread := types.ChirpThread{}
+	if len(replies) > limit {
+		replies = replies[:limit]
+		thread.NextCursor = encodeChirpCursor(replies[limit-1])
+	}
+
+	// looking up likes once for the whole thread
+	res, err := chirpResponses(r, cfg, slices.Concat(ancestors, []database.Chirp{chirp}, replies))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error looking for likes: %s", err), err)
+		return
+	}
+	thread.Ancestors = res[:len(ancestors)]
+	thread.Chirp = res[len(ancestors)]
+	thread.Replies = res[len(ancestors)+1:]
+
+	respondWithJSON(w, http.StatusOK, thread)
+}
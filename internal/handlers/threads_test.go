@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kevinjimenez96/chirpy/internal/auth"
+	"github.com/kevinjimenez96/chirpy/internal/database"
+	"github.com/kevinjimenez96/chirpy/internal/types"
+)
+
+func TestChirpThread(t *testing.T) {
+	server, cfg := testServer(t)
+	store := cfg.DbQueries
+
+	ctx := context.Background()
+	user, _ := store.CreateUser(ctx, database.CreateUserParams{Email: "a@example.com", HashedPassword: "hash"})
+	store.VerifyUserEmail(ctx, database.VerifyUserEmailParams{ID: user.ID, Email: user.Email})
+	token, _ := auth.MakeJWT(user.ID, auth.RoleUser, cfg.Keys, time.Hour)
+
+	post := func(body string, replyTo *uuid.UUID) database.Chirp {
+		t.Helper()
+		chirp := database.Chirp{}
+		req := types.AddChirpReq{Chirp: types.Chirp{Body: body}, ReplyToID: replyTo}
+		if status := doJSON(t, http.MethodPost, server.URL+"/api/chirps", token, req, &chirp); status != http.StatusCreated {
+			t.Fatalf("POST /api/chirps = %d, want 201", status)
+		}
+		return chirp
+	}
+	root := post("root", nil)
+	reply := post("reply", &root.ID)
+	nested := post("nested", &reply.ID)
+	second := post("second", &root.ID)
+	if reply.ReplyToID.UUID != root.ID {
+		t.Errorf("reply_to_id = %v, want %v", reply.ReplyToID, root.ID)
+	}
+	unknown := uuid.New()
+	if status := doJSON(t, http.MethodPost, server.URL+"/api/chirps", token, types.AddChirpReq{Chirp: types.Chirp{Body: "orphan"}, ReplyToID: &unknown}, nil); status != http.StatusBadRequest {
+		t.Errorf("POST a reply to an unknown chirp = %d, want 400", status)
+	}
+
+	thread := types.ChirpThread{}
+	status := doJSON(t, http.MethodGet, server.URL+"/api/chirps/"+nested.ID.String()+"/thread", "", nil, &thread)
+	if status != http.StatusOK || len(thread.Ancestors) != 2 || thread.Ancestors[0].ID != root.ID || thread.Ancestors[1].ID != reply.ID {
+		t.Fatalf("GET thread of nested = %d, %+v, want root and reply as ancestors", status, thread)
+	}
+	if thread.Ancestors[0].ReplyCount != 2 || len(thread.Replies) != 0 {
+		t.Errorf("GET thread of nested = %+v, want two replies to root and none to nested", thread)
+	}
+
+	// replies are paged oldest first, nested ones included
+	var seen []uuid.UUID
+	url := server.URL + "/api/chirps/" + root.ID.String() + "/thread?limit=2"
+	for {
+		thread := types.ChirpThread{}
+		if status := doJSON(t, http.MethodGet, url, "", nil, &thread); status != http.StatusOK {
+			t.Fatalf("GET thread of root = %d, want 200", status)
+		}
+		for _, reply := range thread.Replies {
+			seen = append(seen, reply.ID)
+		}
+		if thread.NextCursor == "" {
+			break
+		}
+		url = server.URL + "/api/chirps/" + root.ID.String() + "/thread?limit=2&cursor=" + thread.NextCursor
+	}
+	if len(seen) != 3 || seen[0] != reply.ID || seen[1] != nested.ID || seen[2] != second.ID {
+		t.Errorf("replies to root = %v, want reply, nested, second", seen)
+	}
+
+	// deleting a chirp with replies keeps it in the thread without its body
+	if status := doJSON(t, http.MethodDelete, server.URL+"/api/chirps/"+reply.ID.String(), token, nil, nil); status != http.StatusNoContent {
+		t.Fatalf("DELETE reply = %d, want 204", status)
+	}
+	if status := doJSON(t, http.MethodGet, server.URL+"/api/chirps/"+reply.ID.String(), "", nil, nil); status != http.StatusNotFound {
+		t.Errorf("GET deleted reply = %d, want 404", status)
+	}
+	thread = types.ChirpThread{}
+	doJSON(t, http.MethodGet, server.URL+"/api/chirps/"+nested.ID.String()+"/thread", "", nil, &thread)
+	if len(thread.Ancestors) != 2 || !thread.Ancestors[1].Deleted || thread.Ancestors[1].Body != "" {
+		t.Errorf("GET thread of nested = %+v, want the deleted reply without its body", thread)
+	}
+	if thread.Ancestors[0].ReplyCount != 1 {
+		t.Errorf("reply_count of root = %d, want 1", thread.Ancestors[0].ReplyCount)
+	}
+
+	// chirps without replies are deleted outright
+	if status := doJSON(t, http.MethodDelete, server.URL+"/api/chirps/"+second.ID.String(), token, nil, nil); status != http.StatusNoContent {
+		t.Fatalf("DELETE second = %d, want 204", status)
+	}
+	thread = types.ChirpThread{}
+	doJSON(t, http.MethodGet, server.URL+"/api/chirps/"+root.ID.String()+"/thread", "", nil, &thread)
+	if thread.Chirp.ReplyCount != 0 || len(thread.Replies) != 2 {
+		t.Errorf("GET thread of root = %+v, want the deleted reply and nested", thread)
+	}
+}
+
+func TestAddChirpRespondsLikeGet(t *testing.T) {
+	server, cfg := testServer(t)
+	_, token := createTestUser(t, cfg, "a@example.com", "password1")
+
+	root := types.ChirpRes{}
+	doJSON(t, http.MethodPost, server.URL+"/api/chirps", token, types.AddChirpReq{Chirp: types.Chirp{Body: "root"}}, &root)
+	created := map[string]any{}
+	req := types.AddChirpReq{Chirp: types.Chirp{Body: "reply"}, ReplyToID: &root.ID}
+	if status := doJSON(t, http.MethodPost, server.URL+"/api/chirps", token, req, &created); status != http.StatusCreated {
+		t.Fatalf("POST a reply = %d, want 201", status)
+	}
+
+	read := map[string]any{}
+	doJSON(t, http.MethodGet, server.URL+"/api/chirps/"+created["id"].(string), token, nil, &read)
+	if !reflect.DeepEqual(created, read) {
+		t.Errorf("POST /api/chirps = %v, GET it back = %v, want the same", created, read)
+	}
+	if created["reply_to_id"] != root.ID.String() || created["liked_by_me"] != false {
+		t.Errorf("POST a reply = %v, want reply_to_id and liked_by_me", created)
+	}
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kevinjimenez96/chirpy/internal/auth"
+	"github.com/kevinjimenez96/chirpy/internal/database"
+	"github.com/kevinjimenez96/chirpy/internal/types"
+)
+
+// twoFactorChallengeLifetime is how long a user has to enter their code
+// after getting their password right.
+const twoFactorChallengeLifetime = 5 * time.Minute
+
+// totpIssuer names the service in authenticator apps.
+const totpIssuer = "Chirpy"
+
+// recoveryCodeCount is how many recovery codes come with an enrollment.
+const recoveryCodeCount = 10
+
+// twoFactorThrottleKey keys the failed codes of a user in the account
+// throttle, apart from their failed passwords.
+func twoFactorThrottleKey(userID uuid.UUID) string {
+	return "2fa:" + userID.String()
+}
+
+func EnrollTOTPHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := cfg.DbQueries.GetUserById(r.Context(), principal.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error looking for user: %s", err), err)
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error generating TOTP secret: %s", err), err)
+		return
+	}
+
+	_, err = cfg.DbQueries.UpsertUserTotp(r.Context(), database.UpsertUserTotpParams{
+		UserID: user.ID,
+		Secret: secret,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusConflict, "Error: 2FA is already enabled", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error saving TOTP secret: %s", err), err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, types.EnrollTOTPRes{
+		Secret: secret,
+		URI:    auth.TOTPURI(totpIssuer, user.Email, secret),
+	})
+}
+
+// ConfirmTOTPHandler enables 2FA once the user proves their authenticator
+// produces the right codes, and hands out the recovery codes.
+func ConfirmTOTPHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	confirmTOTPReq := types.ConfirmTOTPReq{}
+	err := decoder.Decode(&confirmTOTPReq)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Error decoding confirm 2FA request: %s", err), err)
+		return
+	}
+
+	totp, err := cfg.DbQueries.GetUserTotp(r.Context(), principal.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "Error: no 2FA enrollment to confirm", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error looking for 2FA enrollment: %s", err), err)
+		return
+	}
+	if totp.ConfirmedAt.Valid {
+		respondWithError(w, http.StatusConflict, "Error: 2FA is already enabled", nil)
+		return
+	}
+
+	throttleKey := twoFactorThrottleKey(principal.UserID)
+	if wait, ok := cfg.AccountThrottle.Attempt(throttleKey); !ok {
+		allowAttempt(w, wait)
+		return
+	}
+	step, err := auth.ValidateTOTPCode(totp.Secret, confirmTOTPReq.Code, time.Now(), totp.LastUsedStep)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Error: invalid code", err)
+		return
+	}
+	cfg.AccountThrottle.Reset(throttleKey)
+
+	recoveryCodes, err := auth.MakeRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error generating recovery codes: %s", err), err)
+		return
+	}
+	codeHashes := make([]string, len(recoveryCodes))
+	for i, code := range recoveryCodes {
+		codeHashes[i] = auth.HashRecoveryCode(code)
+	}
+
+	err = cfg.DbQueries.DeleteTotpRecoveryCodesForUser(r.Context(), principal.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error deleting old recovery codes: %s", err), err)
+		return
+	}
+	err = cfg.DbQueries.CreateTotpRecoveryCodes(r.Context(), database.CreateTotpRecoveryCodesParams{
+		CodeHashes: codeHashes,
+		UserID:     principal.UserID,
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error saving recovery codes: %s", err), err)
+		return
+	}
+
+	// confirmed last, so that 2FA is never enabled without recovery codes
+	_, err = cfg.DbQueries.ConfirmUserTotp(r.Context(), database.ConfirmUserTotpParams{
+		UserID:       principal.UserID,
+		LastUsedStep: step,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusConflict, "Error: 2FA is already enabled", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error enabling 2FA: %s", err), err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, types.ConfirmTOTPRes{
+		RecoveryCodes: recoveryCodes,
+	})
+}
+
+// LoginTwoFactorHandler completes a login started at POST /api/login by
+// exchanging the challenge token and a TOTP or recovery code for a session.
+func LoginTwoFactorHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	decoder := json.NewDecoder(r.Body)
+	loginTwoFactorReq := types.LoginTwoFactorReq{}
+	err := decoder.Decode(&loginTwoFactorReq)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Error decoding 2FA login request: %s", err), err)
+		return
+	}
+
+	userID, err := auth.ValidateTwoFactorChallengeToken(loginTwoFactorReq.ChallengeToken, cfg.Keys)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, fmt.Sprintf("Invalid challenge token: %s", err), err)
+		return
+	}
+
+	throttleKey := twoFactorThrottleKey(userID)
+	ipKey := clientIP(r)
+	// attempts are counted before the code is checked, like passwords are
+	wait, accountOK := cfg.AccountThrottle.Attempt(throttleKey)
+	ipWait, ipOK := cfg.IPThrottle.Attempt(ipKey)
+	if !accountOK || !ipOK {
+		allowAttempt(w, max(wait, ipWait))
+		return
+	}
+
+	user, err := cfg.DbQueries.GetUserById(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error looking for user: %s", err), err)
+		return
+	}
+	// the account may have changed since the password was checked
+	if user.BannedAt.Valid {
+		respondWithError(w, http.StatusForbidden, "Error: account banned", nil)
+		return
+	}
+	if user.DeletedAt.Valid {
+		respondWithError(w, http.StatusForbidden, "Error: account deleted, restore it with POST /api/users/me/restore", nil)
+		return
+	}
+
+	err = checkSecondFactor(r, cfg, userID, loginTwoFactorReq)
+	if errors.Is(err, auth.ErrInvalidTOTPCode) {
+		respondWithError(w, http.StatusUnauthorized, "Error: invalid code", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error checking code: %s", err), err)
+		return
+	}
+	cfg.AccountThrottle.Reset(throttleKey)
+	cfg.IPThrottle.Undo(ipKey)
+
+	respondWithSession(w, r, cfg, user)
+}
+
+// checkSecondFactor checks and uses up the TOTP or recovery code of req. It
+// returns auth.ErrInvalidTOTPCode for codes that are wrong or already used.
+func checkSecondFactor(r *http.Request, cfg *types.ApiConfig, userID uuid.UUID, req types.LoginTwoFactorReq) error {
+	if req.RecoveryCode != "" {
+		_, err := cfg.DbQueries.UseTotpRecoveryCode(r.Context(), database.UseTotpRecoveryCodeParams{
+			CodeHash: auth.HashRecoveryCode(req.RecoveryCode),
+			UserID:   userID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return auth.ErrInvalidTOTPCode
+		}
+		return err
+	}
+
+	totp, err := cfg.DbQueries.GetUserTotp(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return auth.ErrInvalidTOTPCode
+	}
+	if err != nil {
+		return err
+	}
+	if !totp.ConfirmedAt.Valid {
+		return auth.ErrInvalidTOTPCode
+	}
+
+	step, err := auth.ValidateTOTPCode(totp.Secret, req.Code, time.Now(), totp.LastUsedStep)
+	if err != nil {
+		return err
+	}
+	// no row comes back when a concurrent request used this code first
+	_, err = cfg.DbQueries.UseTotpStep(r.Context(), database.UseTotpStepParams{
+		UserID:       userID,
+		LastUsedStep: step,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return auth.ErrInvalidTOTPCode
+	}
+	return err
+}
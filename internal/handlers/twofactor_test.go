@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kevinjimenez96/chirpy/internal/types"
+)
+
+// totpCodeAt computes the code an authenticator app shows for secret at
+// time step, the 30-second period counted from the Unix epoch.
+func totpCodeAt(t *testing.T, secret string, step int64) string {
+	t.Helper()
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatalf("DecodeString(%q) error = %v", secret, err)
+	}
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[offset:offset+4])&0x7fffffff)%1000000)
+}
+
+func TestTwoFactorLogin(t *testing.T) {
+	server, cfg := testServer(t)
+	user, _ := createTestUser(t, cfg, "a@example.com", "password1")
+	session := login(t, server, "a@example.com", "password1")
+	credentials := types.LoginUserReq{Email: "a@example.com", Password: "password1"}
+
+	enrollment := types.EnrollTOTPRes{}
+	if status := doJSON(t, http.MethodPost, server.URL+"/api/users/me/2fa", session.Token, nil, &enrollment); status != http.StatusCreated {
+		t.Fatalf("POST /api/users/me/2fa = %d, want 201", status)
+	}
+	// until it is confirmed, logging in still takes only the password
+	login(t, server, "a@example.com", "password1")
+
+	step := time.Now().Unix() / 30
+	// a code from long ago stands in for a wrong one
+	wrongCode := totpCodeAt(t, enrollment.Secret, step-100)
+	if status := doJSON(t, http.MethodPost, server.URL+"/api/users/me/2fa/confirm", session.Token, types.ConfirmTOTPReq{Code: wrongCode}, nil); status != http.StatusBadRequest {
+		t.Errorf("POST /api/users/me/2fa/confirm with a wrong code = %d, want 400", status)
+	}
+	confirmation := types.ConfirmTOTPRes{}
+	if status := doJSON(t, http.MethodPost, server.URL+"/api/users/me/2fa/confirm", session.Token, types.ConfirmTOTPReq{Code: totpCodeAt(t, enrollment.Secret, step)}, &confirmation); status != http.StatusOK {
+		t.Fatalf("POST /api/users/me/2fa/confirm = %d, want 200", status)
+	}
+	if len(confirmation.RecoveryCodes) != recoveryCodeCount {
+		t.Errorf("POST /api/users/me/2fa/confirm = %d recovery codes, want %d", len(confirmation.RecoveryCodes), recoveryCodeCount)
+	}
+
+	challenge := func() string {
+		t.Helper()
+		res := types.TwoFactorChallengeRes{}
+		if status := doJSON(t, http.MethodPost, server.URL+"/api/login", "", credentials, &res); status != http.StatusOK || !res.TwoFactorRequired || res.ChallengeToken == "" {
+			t.Fatalf("POST /api/login with 2FA enabled = %d %+v, want 200 and a challenge", status, res)
+		}
+		return res.ChallengeToken
+	}
+	loginTwoFactor := func(req types.LoginTwoFactorReq) (int, types.LoginUserRes) {
+		t.Helper()
+		res := types.LoginUserRes{}
+		status := doJSON(t, http.MethodPost, server.URL+"/api/login/2fa", "", req, &res)
+		return status, res
+	}
+	challengeToken := challenge()
+
+	// a challenge token only completes the login it was handed out for
+	if status := doJSON(t, http.MethodGet, server.URL+"/api/users/me", challengeToken, nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("GET /api/users/me with a challenge token = %d, want 401", status)
+	}
+
+	// wrong codes are throttled per account, apart from wrong passwords
+	for range testThrottlePolicy.FreeAttempts + 1 {
+		if status, _ := loginTwoFactor(types.LoginTwoFactorReq{ChallengeToken: challengeToken, Code: wrongCode}); status != http.StatusUnauthorized {
+			t.Errorf("POST /api/login/2fa with a wrong code = %d, want 401", status)
+		}
+	}
+	nextCode := totpCodeAt(t, enrollment.Secret, step+1)
+	if status, _ := loginTwoFactor(types.LoginTwoFactorReq{ChallengeToken: challengeToken, Code: nextCode}); status != http.StatusTooManyRequests {
+		t.Errorf("POST /api/login/2fa after %d wrong codes = %d, want 429", testThrottlePolicy.FreeAttempts+1, status)
+	}
+	// the password still gets through, only the codes have to wait
+	challengeToken = challenge()
+	cfg.AccountThrottle.Reset(twoFactorThrottleKey(user.ID))
+
+	status, res := loginTwoFactor(types.LoginTwoFactorReq{ChallengeToken: challengeToken, Code: nextCode})
+	if status != http.StatusOK || res.Token == "" {
+		t.Fatalf("POST /api/login/2fa = %d %+v, want 200 and a session", status, res)
+	}
+	if status := doJSON(t, http.MethodGet, server.URL+"/api/users/me", res.Token, nil, nil); status != http.StatusOK {
+		t.Errorf("GET /api/users/me after 2FA = %d, want 200", status)
+	}
+	if status, _ := loginTwoFactor(types.LoginTwoFactorReq{ChallengeToken: challenge(), Code: nextCode}); status != http.StatusUnauthorized {
+		t.Errorf("POST /api/login/2fa with a used code = %d, want 401", status)
+	}
+
+	// a recovery code works exactly once
+	recoveryCode := confirmation.RecoveryCodes[0]
+	if status, _ := loginTwoFactor(types.LoginTwoFactorReq{ChallengeToken: challenge(), RecoveryCode: recoveryCode}); status != http.StatusOK {
+		t.Errorf("POST /api/login/2fa with a recovery code = %d, want 200", status)
+	}
+	if status, _ := loginTwoFactor(types.LoginTwoFactorReq{ChallengeToken: challenge(), RecoveryCode: recoveryCode}); status != http.StatusUnauthorized {
+		t.Errorf("POST /api/login/2fa with a used recovery code = %d, want 401", status)
+	}
+}
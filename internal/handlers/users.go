@@ -1,16 +1,28 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/kevinjimenez96/chirpy/internal/auth"
 	"github.com/kevinjimenez96/chirpy/internal/database"
 	"github.com/kevinjimenez96/chirpy/internal/types"
+	"github.com/lib/pq"
 )
 
+// incorrectCredentialsMessage is the one answer to every wrong email and
+// password, whatever was wrong about them.
+const incorrectCredentialsMessage = "Error: incorrect email or password"
+
 func LoginHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
 	decoder := json.NewDecoder(r.Body)
 	loginUserReq := types.LoginUserReq{}
@@ -20,40 +32,223 @@ func LoginHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig)
 		return
 	}
 
-	loggedUser, err := cfg.DbQueries.GetUserByEmail(r.Context(), loginUserReq.Email)
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error looking for user: %s", err), err)
+	loggedUser, ok := checkPassword(w, r, cfg, loginUserReq)
+	if !ok {
 		return
 	}
-	err = auth.CheckPasswordHash(loginUserReq.Password, loggedUser.HashedPassword)
+	acceptAttempt(r, cfg, loginUserReq.Email)
+
+	// the plain password is only at hand now, so this is when an outdated
+	// hash can be upgraded
+	if cfg.Passwords.NeedsRehash(loggedUser.HashedPassword) {
+		rehashPassword(r, cfg, loggedUser.ID, loginUserReq.Password)
+	}
+
+	finishLogin(w, r, cfg, loggedUser)
+}
+
+// checkPassword looks up the account of an email and password, throttled
+// per account and per client address. It responds with a 401, or a 429 when
+// the caller has to wait, and returns false unless the password is right.
+// The attempt counts as a failure until it is passed to acceptAttempt.
+func checkPassword(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig, req types.LoginUserReq) (database.User, bool) {
+	// attempts are counted before the password is checked, so parallel
+	// requests can't all guess before the first one fails
+	wait, accountOK := cfg.AccountThrottle.Attempt(strings.ToLower(req.Email))
+	ipWait, ipOK := cfg.IPThrottle.Attempt(clientIP(r))
+	if !accountOK || !ipOK {
+		allowAttempt(w, max(wait, ipWait))
+		return database.User{}, false
+	}
+
+	user, err := cfg.DbQueries.GetUserByEmail(r.Context(), req.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		// unknown emails take as long and are throttled like existing ones,
+		// so neither tells them apart
+		cfg.Passwords.CheckDummy(req.Password)
+		respondWithError(w, http.StatusUnauthorized, incorrectCredentialsMessage, nil)
+		return database.User{}, false
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error looking for user", err)
+		return database.User{}, false
+	}
+	err = cfg.Passwords.Check(req.Password, user.HashedPassword)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, fmt.Sprintf("Error: Incorrect email or passwordt: %s", err), err)
+		respondWithError(w, http.StatusUnauthorized, incorrectCredentialsMessage, err)
+		return database.User{}, false
+	}
+	return user, true
+}
+
+// acceptAttempt takes back an attempt that checkPassword counted, once it
+// succeeded.
+func acceptAttempt(r *http.Request, cfg *types.ApiConfig, email string) {
+	cfg.AccountThrottle.Reset(strings.ToLower(email))
+	// the address keeps its earlier failures, or logging into an own
+	// account would let it guess on
+	cfg.IPThrottle.Undo(clientIP(r))
+}
+
+// finishLogin logs in user, who proved who they are, unless their account
+// is banned or deleted. With 2FA enabled they only get a challenge to
+// answer at POST /api/login/2fa.
+func finishLogin(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig, user database.User) {
+	if user.BannedAt.Valid {
+		respondWithError(w, http.StatusForbidden, "Error: account banned", nil)
 		return
 	}
 
-	refreshToken, _ := auth.MakeRefreshToken()
-	cfg.DbQueries.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
-		UserID: loggedUser.ID,
-		Token:  refreshToken,
-	})
+	if user.DeletedAt.Valid {
+		respondWithError(w, http.StatusForbidden, "Error: account deleted, restore it with POST /api/users/me/restore", nil)
+		return
+	}
+
+	totp, err := cfg.DbQueries.GetUserTotp(r.Context(), user.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error looking for 2FA settings: %s", err), err)
+		return
+	}
+	if err == nil && totp.ConfirmedAt.Valid {
+		challengeToken, err := auth.MakeTwoFactorChallengeToken(user.ID, cfg.Keys, twoFactorChallengeLifetime)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error: challenge token creation error: %s", err), err)
+			return
+		}
+		respondWithJSON(w, http.StatusOK, types.TwoFactorChallengeRes{
+			TwoFactorRequired: true,
+			ChallengeToken:    challengeToken,
+		})
+		return
+	}
 
-	token, err := auth.MakeJWT(loggedUser.ID, cfg.Secret, time.Duration(1)*time.Hour)
+	respondWithSession(w, r, cfg, user)
+}
+
+// respondWithSession starts a session for user, who has been fully
+// authenticated, and responds with its tokens.
+func respondWithSession(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig, user database.User) {
+	token, err := auth.MakeAccessToken(user.ID, user.Role, cfg.Keys, time.Duration(1)*time.Hour)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, fmt.Sprintf("Error: token creation error: %s", err), err)
 		return
 	}
 
+	refreshToken, err := createRefreshToken(r, cfg, user.ID, uuid.New(), sql.NullTime{}, token, uuid.NullUUID{}, nil)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error: refresh token creation error: %s", err), err)
+		return
+	}
+
 	respondWithJSON(w, http.StatusOK, types.LoginUserRes{
-		ID:           loggedUser.ID,
-		CreatedAt:    loggedUser.CreatedAt,
-		UpdatedAt:    loggedUser.UpdatedAt,
-		Email:        loggedUser.Email,
-		Token:        token,
+		ID:           user.ID,
+		CreatedAt:    user.CreatedAt,
+		UpdatedAt:    user.UpdatedAt,
+		Email:        user.Email,
+		Token:        token.Token,
 		RefreshToken: refreshToken,
-		IsChirpyRed:  loggedUser.IsChirpyRed.Bool,
+		IsChirpyRed:  user.IsChirpyRed.Bool,
+		Role:         user.Role,
+		IsVerified:   user.VerifiedAt.Valid,
+	})
+}
+
+// allowAttempt responds with a 429 and returns false when the caller has to
+// wait before trying again.
+func allowAttempt(w http.ResponseWriter, wait time.Duration) bool {
+	if wait <= 0 {
+		return true
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	respondWithError(w, http.StatusTooManyRequests, "Error: too many failed attempts, try again later", nil)
+	return false
+}
+
+// rehashPassword stores a hash of password made with the current hashing
+// settings. Failing to do so doesn't fail the login, so errors are only
+// logged.
+func rehashPassword(r *http.Request, cfg *types.ApiConfig, userID uuid.UUID, password string) {
+	hashedPassword, err := cfg.Passwords.Hash(password)
+	if err != nil {
+		log.Printf("Error rehashing password of user %s: %s", userID, err)
+		return
+	}
+
+	_, err = cfg.DbQueries.UpdateUserPassword(r.Context(), database.UpdateUserPasswordParams{
+		ID:             userID,
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		log.Printf("Error saving rehashed password of user %s: %s", userID, err)
+	}
+}
+
+// createRefreshToken stores a new refresh token for userID in familyID and
+// returns its value. lastUsedAt is set when the token replaces one that was
+// just used, so the session shows when it was last active. accessToken is the
+// access token issued alongside it, revoked along with the session. clientID
+// and scopes are set for tokens issued to OAuth clients.
+func createRefreshToken(r *http.Request, cfg *types.ApiConfig, userID, familyID uuid.UUID, lastUsedAt sql.NullTime, accessToken auth.AccessToken, clientID uuid.NullUUID, scopes []string) (string, error) {
+	refreshToken, err := auth.MakeRefreshToken()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = cfg.DbQueries.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
+		UserID:     userID,
+		TokenHash:  auth.HashRefreshToken(refreshToken),
+		FamilyID:   familyID,
+		LastUsedAt: lastUsedAt,
+		UserAgent:  userAgent(r),
+		IpAddress:  clientIP(r),
+
+		AccessTokenID:        uuid.NullUUID{UUID: accessToken.ID, Valid: true},
+		AccessTokenExpiresAt: sql.NullTime{Time: accessToken.ExpiresAt.UTC(), Valid: true},
+		ClientID:             clientID,
+		Scopes:               scopes,
 	})
+	if err != nil {
+		return "", err
+	}
+	return refreshToken, nil
+}
+
+// errRefreshTokenReused is returned by rotateRefreshToken when the token was
+// rotated by a concurrent request first.
+var errRefreshTokenReused = errors.New("refresh token already rotated")
+
+// rotateRefreshToken replaces refreshToken with a new token of the same
+// family, issued alongside accessToken, and returns the new token's value.
+func rotateRefreshToken(r *http.Request, cfg *types.ApiConfig, refreshToken database.RefreshToken, accessToken auth.AccessToken) (string, error) {
+	newRefreshToken, err := createRefreshToken(r, cfg, refreshToken.UserID, refreshToken.FamilyID, sql.NullTime{Time: time.Now().UTC(), Valid: true}, accessToken, refreshToken.ClientID, refreshToken.Scopes)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = cfg.DbQueries.RotateRefreshToken(r.Context(), database.RotateRefreshTokenParams{
+		TokenHash:  refreshToken.TokenHash,
+		ReplacedBy: sql.NullString{String: auth.HashRefreshToken(newRefreshToken), Valid: true},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", errRefreshTokenReused
+	}
+	if err != nil {
+		return "", err
+	}
+	return newRefreshToken, nil
 }
 
+// getRefreshToken looks up the stored refresh token matching a raw token
+// value. Only the hash is stored and the lookup is by hash, so any row found
+// is a match.
+func getRefreshToken(r *http.Request, cfg *types.ApiConfig, token string) (database.RefreshToken, error) {
+	return cfg.DbQueries.GetRefreshToken(r.Context(), auth.HashRefreshToken(token))
+}
+
+// RefreshTokenHandler exchanges a refresh token for a new access token and a
+// new refresh token, revoking the one presented. All refresh tokens descending
+// from the same login form a family; presenting a token that was already
+// rotated means it was copied, so the whole family is revoked.
 func RefreshTokenHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
 	token, err := auth.GetBearerToken(r.Header)
 	if err != nil {
@@ -61,22 +256,74 @@ func RefreshTokenHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiC
 		return
 	}
 
-	refreshToken, err := cfg.DbQueries.GetRefreshToken(r.Context(), token)
-	if err != nil || !time.Time.IsZero(refreshToken.RevokedAt.Time) {
-		respondWithError(w, http.StatusUnauthorized, fmt.Sprintf("Error looking for token or expired: %s", err), err)
+	refreshToken, err := getRefreshToken(r, cfg, token)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, fmt.Sprintf("Error looking for token: %s", err), err)
+		return
+	}
+	// tokens of OAuth clients are refreshed at POST /api/oauth/token
+	if refreshToken.ClientID.Valid {
+		respondWithError(w, http.StatusUnauthorized, "Error: token revoked or expired", nil)
 		return
 	}
 
-	newToken, err := auth.MakeJWT(refreshToken.UserID, cfg.Secret, time.Duration(1)*time.Hour)
-	if err != nil || time.Time.IsZero(refreshToken.ExpiresAt) {
+	if refreshToken.ReplacedBy.Valid {
+		revokeRefreshTokenFamily(w, r, cfg, refreshToken)
+		return
+	}
+
+	if refreshToken.RevokedAt.Valid || time.Now().After(refreshToken.ExpiresAt) {
+		respondWithError(w, http.StatusUnauthorized, "Error: token revoked or expired", nil)
+		return
+	}
+
+	user, err := cfg.DbQueries.GetUserById(r.Context(), refreshToken.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, fmt.Sprintf("Error looking for user: %s", err), err)
+		return
+	}
+
+	if user.BannedAt.Valid || user.DeletedAt.Valid {
+		respondWithError(w, http.StatusForbidden, "Error: account banned or deleted", nil)
+		return
+	}
+
+	newToken, err := auth.MakeAccessToken(user.ID, user.Role, cfg.Keys, time.Duration(1)*time.Hour)
+	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error creating new token: %s", err), err)
 		return
 	}
+
+	newRefreshToken, err := rotateRefreshToken(r, cfg, refreshToken, newToken)
+	if errors.Is(err, errRefreshTokenReused) {
+		// a concurrent request rotated the same token first
+		revokeRefreshTokenFamily(w, r, cfg, refreshToken)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error rotating refresh token: %s", err), err)
+		return
+	}
+
 	respondWithJSON(w, http.StatusOK, types.RefreshTokenRes{
-		Token: newToken,
+		Token:        newToken.Token,
+		RefreshToken: newRefreshToken,
 	})
 }
 
+// revokeRefreshTokenFamily handles the reuse of an already rotated refresh
+// token by revoking every token of its family.
+func revokeRefreshTokenFamily(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig, refreshToken database.RefreshToken) {
+	log.Printf("Refresh token reuse detected for user %s, revoking family %s", refreshToken.UserID, refreshToken.FamilyID)
+
+	err := revokeSession(r, cfg, refreshToken.FamilyID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error revoking token family: %s", err), err)
+		return
+	}
+	respondWithError(w, http.StatusUnauthorized, "Error: token revoked or expired", nil)
+}
+
 func RevokeHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
 	token, err := auth.GetBearerToken(r.Header)
 	if err != nil {
@@ -84,12 +331,26 @@ func RevokeHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig)
 		return
 	}
 
-	_, err = cfg.DbQueries.RevokeToken(r.Context(), token)
+	refreshToken, err := getRefreshToken(r, cfg, token)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, fmt.Sprintf("Error looking for token: %s", err), err)
+		return
+	}
+
+	_, err = cfg.DbQueries.RevokeToken(r.Context(), refreshToken.TokenHash)
 	if err != nil {
 		respondWithError(w, http.StatusUnauthorized, fmt.Sprintf("Error revoking for token: %s", err), err)
 		return
 	}
 
+	// logging out ends the whole session, not just its latest token, and
+	// invalidates the access tokens issued for it
+	err = revokeSession(r, cfg, refreshToken.FamilyID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error revoking token family: %s", err), err)
+		return
+	}
+
 	respondWithJSON(w, http.StatusNoContent, nil)
 }
 
@@ -102,7 +363,15 @@ func AddUserHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig
 		return
 	}
 
-	hashedPassword, err := auth.HashPassword(createUserReq.Password)
+	if !validEmail(createUserReq.Email) {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid email: %q", createUserReq.Email), nil)
+		return
+	}
+	if !checkPasswordPolicy(w, cfg, createUserReq.Password) {
+		return
+	}
+
+	hashedPassword, err := cfg.Passwords.Hash(createUserReq.Password)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error creating user: %s", err), err)
 		return
@@ -117,48 +386,244 @@ func AddUserHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig
 		return
 	}
 
+	// the account exists either way; the user can ask for another email
+	if err := sendVerificationEmail(r, cfg, newUser); err != nil {
+		log.Printf("Error sending verification email to user %s: %s", newUser.ID, err)
+	}
+
 	respondWithJSON(w, http.StatusCreated, types.LoginUserRes{
 		ID:          newUser.ID,
 		CreatedAt:   newUser.CreatedAt,
 		UpdatedAt:   newUser.UpdatedAt,
 		Email:       newUser.Email,
 		IsChirpyRed: newUser.IsChirpyRed.Bool,
+		Role:        newUser.Role,
+		IsVerified:  newUser.VerifiedAt.Valid,
+	})
+}
+
+// GetCurrentUserHandler returns the profile of the caller.
+func GetCurrentUserHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := cfg.DbQueries.GetUserById(r.Context(), principal.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error looking for user: %s", err), err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, types.LoginUserRes{
+		ID:          user.ID,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+		Email:       user.Email,
+		IsChirpyRed: user.IsChirpyRed.Bool,
+		Role:        user.Role,
+		IsVerified:  user.VerifiedAt.Valid,
 	})
 }
 
 func UpdateUserHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
-	token, _ := auth.GetBearerToken(r.Header)
-	userId, _ := auth.ValidateJWT(token, cfg.Secret)
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
 
 	decoder := json.NewDecoder(r.Body)
 	updateUserReq := types.UpdateUserReq{}
 	err := decoder.Decode(&updateUserReq)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error decoding update user request: %s", err), err)
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Error decoding update user request: %s", err), err)
 		return
 	}
 
-	hashedPassword, err := auth.HashPassword(updateUserReq.Password)
+	if updateUserReq.Email != nil && !validEmail(*updateUserReq.Email) {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid email: %q", *updateUserReq.Email), nil)
+		return
+	}
+	if updateUserReq.Password != nil && !checkPasswordPolicy(w, cfg, *updateUserReq.Password) {
+		return
+	}
+
+	user, err := cfg.DbQueries.GetUserById(r.Context(), principal.UserID)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error updating user: %s", err), err)
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error looking for user: %s", err), err)
 		return
 	}
 
+	// a password sent at all counts as a change: comparing it with the old
+	// one would tell a caller who doesn't know it whether they guessed right
+	emailChanged := updateUserReq.Email != nil && *updateUserReq.Email != user.Email
+	passwordChanged := updateUserReq.Password != nil
+
+	if updateUserReq.Email != nil || passwordChanged {
+		err = cfg.Passwords.Check(updateUserReq.CurrentPassword, user.HashedPassword)
+		if err != nil {
+			respondWithError(w, http.StatusForbidden, "Error: current_password is incorrect", nil)
+			return
+		}
+	}
+
+	email := user.Email
+	if emailChanged {
+		email = *updateUserReq.Email
+	}
+
+	hashedPassword := user.HashedPassword
+	if passwordChanged {
+		hashedPassword, err = cfg.Passwords.Hash(*updateUserReq.Password)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error updating user: %s", err), err)
+			return
+		}
+	}
+
 	newUser, err := cfg.DbQueries.UpdateUser(r.Context(), database.UpdateUserParams{
-		ID:             userId,
-		Email:          updateUserReq.Email,
+		ID:             principal.UserID,
+		Email:          email,
 		HashedPassword: hashedPassword,
 	})
+	if isUniqueViolation(err) {
+		respondWithError(w, http.StatusConflict, "Email already in use", nil)
+		return
+	}
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error decoding updating user: %s", err), err)
 		return
 	}
 
+	// a new password signs the user out everywhere
+	if passwordChanged {
+		err = revokeAllSessions(r, cfg, newUser.ID)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error revoking sessions: %s", err), err)
+			return
+		}
+	}
+
+	if emailChanged {
+		if err := sendVerificationEmail(r, cfg, newUser); err != nil {
+			log.Printf("Error sending verification email to user %s: %s", newUser.ID, err)
+		}
+	}
+
 	respondWithJSON(w, http.StatusOK, types.LoginUserRes{
 		ID:          newUser.ID,
 		CreatedAt:   newUser.CreatedAt,
 		UpdatedAt:   newUser.UpdatedAt,
 		Email:       newUser.Email,
 		IsChirpyRed: newUser.IsChirpyRed.Bool,
+		Role:        newUser.Role,
+		IsVerified:  newUser.VerifiedAt.Valid,
 	})
 }
+
+// isUniqueViolation reports whether err is a Postgres unique constraint
+// violation. The memory store reports them the same way.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+func UpdateUserRoleHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid user id: %s", err), err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	updateUserRoleReq := types.UpdateUserRoleReq{}
+	err = decoder.Decode(&updateUserRoleReq)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Error decoding update role request: %s", err), err)
+		return
+	}
+
+	if updateUserRoleReq.Role != auth.RoleUser && updateUserRoleReq.Role != auth.RoleAdmin {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid role: %q", updateUserRoleReq.Role), nil)
+		return
+	}
+
+	user, err := cfg.DbQueries.UpdateUserRole(r.Context(), database.UpdateUserRoleParams{
+		ID:   id,
+		Role: updateUserRoleReq.Role,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "User not found", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error updating role: %s", err), err)
+		return
+	}
+
+	// access tokens carry the role they were issued with, so the user is
+	// signed out everywhere and gets the new role with their next login
+	err = revokeAllSessions(r, cfg, id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error revoking sessions: %s", err), err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, types.LoginUserRes{
+		ID:          user.ID,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+		Email:       user.Email,
+		IsChirpyRed: user.IsChirpyRed.Bool,
+		Role:        user.Role,
+		IsVerified:  user.VerifiedAt.Valid,
+	})
+}
+
+// BanUserHandler bans a user, signing them out everywhere. Their access
+// tokens are revoked too, so the ban applies immediately.
+func BanUserHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid user id: %s", err), err)
+		return
+	}
+
+	_, err = cfg.DbQueries.BanUser(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "User not found", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error banning user: %s", err), err)
+		return
+	}
+
+	err = revokeAllSessions(r, cfg, id)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error revoking sessions: %s", err), err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+func UnbanUserHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid user id: %s", err), err)
+		return
+	}
+
+	_, err = cfg.DbQueries.UnbanUser(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusNotFound, "User not found", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error unbanning user: %s", err), err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
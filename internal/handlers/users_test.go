@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kevinjimenez96/chirpy/internal/auth"
+	"github.com/kevinjimenez96/chirpy/internal/database"
+	"github.com/kevinjimenez96/chirpy/internal/types"
+)
+
+func TestRefreshTokenRotation(t *testing.T) {
+	server, cfg := testServer(t)
+	createTestUser(t, cfg, "a@example.com", "password1")
+	session := login(t, server, "a@example.com", "password1")
+
+	first := types.RefreshTokenRes{}
+	if status := doJSON(t, http.MethodPost, server.URL+"/api/refresh", session.RefreshToken, nil, &first); status != http.StatusOK {
+		t.Fatalf("POST /api/refresh = %d, want 200", status)
+	}
+	if first.RefreshToken == "" || first.RefreshToken == session.RefreshToken {
+		t.Fatalf("POST /api/refresh = %+v, want a new refresh token", first)
+	}
+	if status := doJSON(t, http.MethodGet, server.URL+"/api/users/me", first.Token, nil, nil); status != http.StatusOK {
+		t.Errorf("GET /api/users/me with the refreshed token = %d, want 200", status)
+	}
+
+	// presenting a rotated token again revokes the whole family, newest
+	// token and the access tokens issued for it included
+	if status := doJSON(t, http.MethodPost, server.URL+"/api/refresh", session.RefreshToken, nil, nil); status != http.StatusUnauthorized {
+		t.Fatalf("POST /api/refresh with a rotated token = %d, want 401", status)
+	}
+	if status := doJSON(t, http.MethodPost, server.URL+"/api/refresh", first.RefreshToken, nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("POST /api/refresh with the newest token = %d, want 401", status)
+	}
+	if status := doJSON(t, http.MethodGet, server.URL+"/api/users/me", first.Token, nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("GET /api/users/me with a revoked family = %d, want 401", status)
+	}
+
+	// logging out revokes the session too
+	other := login(t, server, "a@example.com", "password1")
+	if status := doJSON(t, http.MethodPost, server.URL+"/api/revoke", other.RefreshToken, nil, nil); status != http.StatusNoContent {
+		t.Fatalf("POST /api/revoke = %d, want 204", status)
+	}
+	if status := doJSON(t, http.MethodPost, server.URL+"/api/refresh", other.RefreshToken, nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("POST /api/refresh with a revoked token = %d, want 401", status)
+	}
+}
+
+func TestUpdateUser(t *testing.T) {
+	server, cfg := testServer(t)
+	_, token := createTestUser(t, cfg, "a@example.com", "password1")
+	createTestUser(t, cfg, "b@example.com", "password1")
+	session := login(t, server, "a@example.com", "password1")
+
+	ptr := func(s string) *string { return &s }
+	update := func(req types.UpdateUserReq) (int, types.LoginUserRes) {
+		t.Helper()
+		res := types.LoginUserRes{}
+		status := doJSON(t, http.MethodPatch, server.URL+"/api/users", token, req, &res)
+		return status, res
+	}
+
+	// an email or a password can't change without the current password,
+	// even when it is the same as before
+	for _, req := range []types.UpdateUserReq{
+		{Email: ptr("c@example.com")},
+		{Email: ptr("c@example.com"), CurrentPassword: "wrong"},
+		{Password: ptr("password1"), CurrentPassword: "wrong"},
+		{Password: ptr("password2")},
+	} {
+		if status, _ := update(req); status != http.StatusForbidden {
+			t.Errorf("PATCH /api/users %+v = %d, want 403", req, status)
+		}
+	}
+
+	if status, _ := update(types.UpdateUserReq{Email: ptr("b@example.com"), CurrentPassword: "password1"}); status != http.StatusConflict {
+		t.Errorf("PATCH /api/users to a taken email = %d, want 409", status)
+	}
+
+	// only the fields sent change
+	status, res := update(types.UpdateUserReq{Email: ptr("c@example.com"), CurrentPassword: "password1"})
+	if status != http.StatusOK || res.Email != "c@example.com" {
+		t.Fatalf("PATCH /api/users email = %d, %+v, want 200 with the new email", status, res)
+	}
+	if status := doJSON(t, http.MethodGet, server.URL+"/api/users/me", session.Token, nil, nil); status != http.StatusOK {
+		t.Errorf("GET /api/users/me after an email change = %d, want 200", status)
+	}
+	login(t, server, "c@example.com", "password1")
+
+	// a new password signs the user out everywhere
+	status, res = update(types.UpdateUserReq{Password: ptr("password2"), CurrentPassword: "password1"})
+	if status != http.StatusOK || res.Email != "c@example.com" {
+		t.Fatalf("PATCH /api/users password = %d, %+v, want 200 with the email kept", status, res)
+	}
+	if status := doJSON(t, http.MethodGet, server.URL+"/api/users/me", session.Token, nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("GET /api/users/me after a password change = %d, want 401", status)
+	}
+	if status := doJSON(t, http.MethodPost, server.URL+"/api/refresh", session.RefreshToken, nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("POST /api/refresh after a password change = %d, want 401", status)
+	}
+	if status := doJSON(t, http.MethodPost, server.URL+"/api/login", "", types.LoginUserReq{Email: "c@example.com", Password: "password1"}, nil); status != http.StatusUnauthorized {
+		t.Errorf("POST /api/login with the old password = %d, want 401", status)
+	}
+	login(t, server, "c@example.com", "password2")
+}
+
+func TestLoginThrottle(t *testing.T) {
+	server, cfg := testServer(t)
+	createTestUser(t, cfg, "a@example.com", "password1")
+	createTestUser(t, cfg, "b@example.com", "password1")
+	createTestUser(t, cfg, "c@example.com", "password1")
+
+	attempt := func(path, email, password string) (int, string) {
+		t.Helper()
+		res := struct {
+			Error string `json:"error"`
+		}{}
+		status := doJSON(t, http.MethodPost, server.URL+path, "", types.LoginUserReq{Email: email, Password: password}, &res)
+		return status, res.Error
+	}
+
+	// unknown emails and wrong passwords look the same
+	unknownStatus, unknownError := attempt("/api/login", "nobody@example.com", "password1")
+	wrongStatus, wrongError := attempt("/api/login", "a@example.com", "wrong")
+	if unknownStatus != http.StatusUnauthorized || unknownStatus != wrongStatus || unknownError != wrongError {
+		t.Errorf("POST /api/login = %d %q for an unknown email and %d %q for a wrong password, want the same 401", unknownStatus, unknownError, wrongStatus, wrongError)
+	}
+
+	// past the free attempts even the right password has to wait
+	for range testThrottlePolicy.FreeAttempts {
+		attempt("/api/login", "a@example.com", "wrong")
+	}
+	body, _ := json.Marshal(types.LoginUserReq{Email: "a@example.com", Password: "password1"})
+	resp, err := http.Post(server.URL+"/api/login", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST /api/login error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") != "60" {
+		t.Errorf("POST /api/login after %d failures = %d, Retry-After %q, want 429 after 60s", testThrottlePolicy.FreeAttempts+1, resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+
+	// restoring a live account answers like a wrong password, and is
+	// throttled along with logging in
+	if status, msg := attempt("/api/users/me/restore", "b@example.com", "password1"); status != wrongStatus || msg != wrongError {
+		t.Errorf("POST /api/users/me/restore of a live account = %d %q, want %d %q", status, msg, wrongStatus, wrongError)
+	}
+	for range testThrottlePolicy.FreeAttempts {
+		attempt("/api/users/me/restore", "b@example.com", "password1")
+	}
+	if status, _ := attempt("/api/login", "b@example.com", "password1"); status != http.StatusTooManyRequests {
+		t.Errorf("POST /api/login after %d restore attempts = %d, want 429", testThrottlePolicy.FreeAttempts+1, status)
+	}
+
+	// parallel guesses can't all get in before the first one fails
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	statuses := map[int]int{}
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status := doJSON(t, http.MethodPost, server.URL+"/api/login", "", types.LoginUserReq{Email: "c@example.com", Password: "wrong"}, nil)
+			mu.Lock()
+			statuses[status]++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if statuses[http.StatusUnauthorized] != testThrottlePolicy.FreeAttempts+1 {
+		t.Errorf("parallel POST /api/login = %v, want %d guesses checked", statuses, testThrottlePolicy.FreeAttempts+1)
+	}
+}
+
+func TestUpdateUserRoleSignsOut(t *testing.T) {
+	server, cfg := testServer(t)
+	admin, _ := createTestUser(t, cfg, "admin@example.com", "password1")
+	_, err := cfg.DbQueries.UpdateUserRole(context.Background(), database.UpdateUserRoleParams{ID: admin.ID, Role: auth.RoleAdmin})
+	if err != nil {
+		t.Fatalf("UpdateUserRole() error = %v", err)
+	}
+	session := login(t, server, "admin@example.com", "password1")
+	if status := doJSON(t, http.MethodGet, server.URL+"/admin/moderation/words", session.Token, nil, nil); status != http.StatusOK {
+		t.Fatalf("GET /admin/moderation/words as an admin = %d, want 200", status)
+	}
+
+	other, _ := createTestUser(t, cfg, "other@example.com", "password1")
+	otherToken, _ := auth.MakeJWT(other.ID, auth.RoleAdmin, cfg.Keys, time.Hour)
+	if status := doJSON(t, http.MethodPut, server.URL+"/admin/users/"+admin.ID.String()+"/role", otherToken, types.UpdateUserRoleReq{Role: auth.RoleUser}, nil); status != http.StatusOK {
+		t.Fatalf("PUT /admin/users/{id}/role = %d, want 200", status)
+	}
+
+	// the old tokens still claim the admin role, so they stop working
+	if status := doJSON(t, http.MethodGet, server.URL+"/admin/moderation/words", session.Token, nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("GET /admin/moderation/words with a token from before the demotion = %d, want 401", status)
+	}
+	if status := doJSON(t, http.MethodPost, server.URL+"/api/refresh", session.RefreshToken, nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("POST /api/refresh after the demotion = %d, want 401", status)
+	}
+	session = login(t, server, "admin@example.com", "password1")
+	if status := doJSON(t, http.MethodGet, server.URL+"/admin/moderation/words", session.Token, nil, nil); status != http.StatusForbidden {
+		t.Errorf("GET /admin/moderation/words after the demotion = %d, want 403", status)
+	}
+}
+
+func TestLoginRehashesPassword(t *testing.T) {
+	server, cfg := testServer(t)
+	createTestUser(t, cfg, "a@example.com", "password1")
+	// the smallest argon2id costs, to keep the test fast
+	cfg.Passwords = &auth.PasswordHasher{
+		Scheme: auth.SchemeArgon2id,
+		Argon2: auth.Argon2Params{Memory: 8, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32},
+	}
+	storedHash := func() string {
+		t.Helper()
+		user, err := cfg.DbQueries.GetUserByEmail(context.Background(), "a@example.com")
+		if err != nil {
+			t.Fatalf("GetUserByEmail() error = %v", err)
+		}
+		return user.HashedPassword
+	}
+	bcryptHash := storedHash()
+
+	// only the right password can upgrade the hash
+	doJSON(t, http.MethodPost, server.URL+"/api/login", "", types.LoginUserReq{Email: "a@example.com", Password: "wrong"}, nil)
+	if hash := storedHash(); hash != bcryptHash {
+		t.Errorf("hash after a failed login = %q, want it unchanged", hash)
+	}
+
+	login(t, server, "a@example.com", "password1")
+	argon2Hash := storedHash()
+	if !strings.HasPrefix(argon2Hash, "$argon2id$") {
+		t.Fatalf("hash after login = %q, want an argon2id hash", argon2Hash)
+	}
+	login(t, server, "a@example.com", "password1")
+	if hash := storedHash(); hash != argon2Hash {
+		t.Errorf("hash after another login = %q, want the up to date hash kept", hash)
+	}
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"time"
+
+	"github.com/kevinjimenez96/chirpy/internal/auth"
+	"github.com/kevinjimenez96/chirpy/internal/database"
+	"github.com/kevinjimenez96/chirpy/internal/mailer"
+	"github.com/kevinjimenez96/chirpy/internal/types"
+)
+
+// emailVerificationLifetime is how long a verification link stays valid.
+const emailVerificationLifetime = 24 * time.Hour
+
+const verificationEmailBody = `Welcome to Chirpy!
+
+Confirm your email address by sending this token to POST /api/users/verify:
+
+%s
+
+The token expires in 24 hours.
+`
+
+// validEmail reports whether email is a bare address such as
+// "user@example.com", without a display name.
+func validEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
+
+// sendVerificationEmail mails user a token proving they own their address.
+func sendVerificationEmail(r *http.Request, cfg *types.ApiConfig, user database.User) error {
+	token, err := auth.MakeEmailVerificationToken(user.ID, user.Email, cfg.Keys, emailVerificationLifetime)
+	if err != nil {
+		return err
+	}
+
+	return cfg.Mailer.Send(r.Context(), mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your Chirpy email address",
+		Body:    fmt.Sprintf(verificationEmailBody, token),
+	})
+}
+
+func VerifyEmailHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	decoder := json.NewDecoder(r.Body)
+	verifyEmailReq := types.VerifyEmailReq{}
+	err := decoder.Decode(&verifyEmailReq)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Error decoding verify email request: %s", err), err)
+		return
+	}
+
+	userID, email, err := auth.ValidateEmailVerificationToken(verifyEmailReq.Token, cfg.Keys)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, fmt.Sprintf("Invalid verification token: %s", err), err)
+		return
+	}
+
+	// no row matches once the address is verified or the user has changed it
+	user, err := cfg.DbQueries.VerifyUserEmail(r.Context(), database.VerifyUserEmailParams{
+		ID:    userID,
+		Email: email,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, http.StatusUnauthorized, "Invalid verification token: email has changed or is already verified", nil)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error verifying email: %s", err), err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, types.LoginUserRes{
+		ID:          user.ID,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+		Email:       user.Email,
+		IsChirpyRed: user.IsChirpyRed.Bool,
+		Role:        user.Role,
+		IsVerified:  user.VerifiedAt.Valid,
+	})
+}
+
+func ResendVerificationEmailHandler(w http.ResponseWriter, r *http.Request, cfg *types.ApiConfig) {
+	principal, ok := requirePrincipal(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := cfg.DbQueries.GetUserById(r.Context(), principal.UserID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error looking for user: %s", err), err)
+		return
+	}
+
+	if user.VerifiedAt.Valid {
+		respondWithError(w, http.StatusConflict, "Email already verified", nil)
+		return
+	}
+
+	err = sendVerificationEmail(r, cfg, user)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error sending verification email: %s", err), err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
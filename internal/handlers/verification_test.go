@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinjimenez96/chirpy/internal/auth"
+	"github.com/kevinjimenez96/chirpy/internal/types"
+)
+
+func TestVerifyEmail(t *testing.T) {
+	server, cfg := testServer(t)
+	sentMail := cfg.Mailer.(*testMailer)
+
+	user := types.LoginUserRes{}
+	if status := doJSON(t, http.MethodPost, server.URL+"/api/users", "", types.CreateUserReq{Email: "a@example.com", Password: "password1"}, &user); status != http.StatusCreated {
+		t.Fatalf("POST /api/users = %d, want 201", status)
+	}
+	session := login(t, server, "a@example.com", "password1")
+	addChirp := func() int {
+		t.Helper()
+		return doJSON(t, http.MethodPost, server.URL+"/api/chirps", session.Token, types.AddChirpReq{Chirp: types.Chirp{Body: "hello"}}, nil)
+	}
+	if status := addChirp(); status != http.StatusForbidden {
+		t.Errorf("POST /api/chirps before verifying = %d, want 403", status)
+	}
+
+	// tokenFrom reads the verification token out of the nth email sent
+	tokenFrom := func(n int) string {
+		t.Helper()
+		messages := waitForMail(t, sentMail, n)
+		if len(messages) < n {
+			t.Fatalf("sent %+v, want %d emails", messages, n)
+		}
+		words := strings.Fields(messages[n-1].Body)
+		i := slices.Index(words, "/api/users/verify:")
+		if i < 0 || i+1 >= len(words) {
+			t.Fatalf("email body = %q, want a verification token", messages[n-1].Body)
+		}
+		return words[i+1]
+	}
+	verify := func(token string) int {
+		t.Helper()
+		return doJSON(t, http.MethodPost, server.URL+"/api/users/verify", "", types.VerifyEmailReq{Token: token}, nil)
+	}
+
+	expired, err := auth.MakeEmailVerificationToken(user.ID, "a@example.com", cfg.Keys, -time.Minute)
+	if err != nil {
+		t.Fatalf("MakeEmailVerificationToken() error = %v", err)
+	}
+	if status := verify(expired); status != http.StatusUnauthorized {
+		t.Errorf("POST /api/users/verify with an expired token = %d, want 401", status)
+	}
+
+	token := tokenFrom(1)
+	verified := types.LoginUserRes{}
+	if status := doJSON(t, http.MethodPost, server.URL+"/api/users/verify", "", types.VerifyEmailReq{Token: token}, &verified); status != http.StatusOK || !verified.IsVerified {
+		t.Fatalf("POST /api/users/verify = %d %+v, want 200 and a verified user", status, verified)
+	}
+	if status := addChirp(); status != http.StatusCreated {
+		t.Errorf("POST /api/chirps once verified = %d, want 201", status)
+	}
+	if status := verify(token); status != http.StatusUnauthorized {
+		t.Errorf("POST /api/users/verify with a used token = %d, want 401", status)
+	}
+
+	// a token only verifies the address it was sent to
+	oldAddress, err := auth.MakeEmailVerificationToken(user.ID, "a@example.com", cfg.Keys, time.Hour)
+	if err != nil {
+		t.Fatalf("MakeEmailVerificationToken() error = %v", err)
+	}
+	newEmail := "b@example.com"
+	update := types.UpdateUserReq{Email: &newEmail, CurrentPassword: "password1"}
+	if status := doJSON(t, http.MethodPatch, server.URL+"/api/users", session.Token, update, nil); status != http.StatusOK {
+		t.Fatalf("PATCH /api/users = %d, want 200", status)
+	}
+	if status := verify(oldAddress); status != http.StatusUnauthorized {
+		t.Errorf("POST /api/users/verify for a changed address = %d, want 401", status)
+	}
+	if status := verify(tokenFrom(2)); status != http.StatusOK {
+		t.Errorf("POST /api/users/verify for the new address = %d, want 200", status)
+	}
+}
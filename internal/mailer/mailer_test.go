@@ -0,0 +1,50 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestWriterMailer(t *testing.T) {
+	var buf bytes.Buffer
+	m := &WriterMailer{W: &buf, From: "chirpy@example.com"}
+
+	err := m.Send(context.Background(), Message{
+		To:      "user@example.com",
+		Subject: "Verify your email",
+		Body:    "line one\nline two",
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	got := buf.String()
+	for _, want := range []string{
+		"From: chirpy@example.com\r\n",
+		"To: user@example.com\r\n",
+		"Subject: Verify your email\r\n",
+		"\r\n\r\nline one\r\nline two",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Send() wrote %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestSendRejectsHeaderInjection(t *testing.T) {
+	var buf bytes.Buffer
+	m := &WriterMailer{W: &buf, From: "chirpy@example.com"}
+
+	err := m.Send(context.Background(), Message{
+		To:      "user@example.com\r\nBcc: victim@example.com",
+		Subject: "Hi",
+	})
+	if err == nil {
+		t.Errorf("Send() accepted a recipient with a line break")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Send() wrote %q for a rejected message", buf.String())
+	}
+}
@@ -0,0 +1,30 @@
+package mailer
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// WriterMailer writes messages to W instead of sending them. Point it at a
+// file or the log during development and in tests.
+type WriterMailer struct {
+	W    io.Writer
+	From string
+
+	mu sync.Mutex
+}
+
+func (m *WriterMailer) Send(ctx context.Context, msg Message) error {
+	if err := validHeader(m.From, msg.To, msg.Subject); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dat := append(format(m.From, msg, time.Now()), "\r\n\r\n"...)
+	_, err := m.W.Write(dat)
+	return err
+}
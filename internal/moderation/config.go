@@ -0,0 +1,105 @@
+package moderation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// Config describes a Chain. It is usually loaded from a JSON file:
+//
+//	{
+//	  "rules": [
+//	    {"type": "words", "name": "censored-words", "action": "mask", "file": "words.txt"},
+//	    {"type": "regex", "name": "links", "action": "flag", "pattern": "https?://\\S+"}
+//	  ]
+//	}
+type Config struct {
+	Rules []RuleConfig `json:"rules"`
+}
+
+type RuleConfig struct {
+	// Type is either "words" or "regex".
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+	Action Action `json:"action"`
+	// Words and File feed a "words" rule; both may be set.
+	Words []string `json:"words"`
+	File  string   `json:"file"`
+	// Pattern is the expression of a "regex" rule.
+	Pattern string `json:"pattern"`
+}
+
+// DefaultWords are the words masked when no configuration is given.
+var DefaultWords = StaticWords{
+	{Text: "kerfuffle"},
+	{Text: "sharbert"},
+	{Text: "fornax"},
+}
+
+// DefaultChain masks DefaultWords.
+func DefaultChain() Chain {
+	return Chain{
+		&WordListRule{Name: "censored-words", Source: DefaultWords, Action: ActionMask},
+	}
+}
+
+func LoadConfig(path string) (Chain, error) {
+	dat, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := Config{}
+	if err := json.Unmarshal(dat, &config); err != nil {
+		return nil, fmt.Errorf("parsing moderation config %s: %w", path, err)
+	}
+	return config.Build()
+}
+
+func (c Config) Build() (Chain, error) {
+	chain := make(Chain, 0, len(c.Rules))
+	for i, ruleConfig := range c.Rules {
+		rule, err := ruleConfig.build()
+		if err != nil {
+			return nil, fmt.Errorf("moderation rule %d: %w", i, err)
+		}
+		chain = append(chain, rule)
+	}
+	return chain, nil
+}
+
+func (rc RuleConfig) build() (Rule, error) {
+	if rc.Name == "" {
+		return nil, fmt.Errorf("missing name")
+	}
+	action, err := ParseAction(string(rc.Action))
+	if err != nil {
+		return nil, err
+	}
+
+	switch rc.Type {
+	case "words":
+		var words StaticWords
+		for _, word := range rc.Words {
+			words = append(words, Word{Text: word})
+		}
+		if rc.File != "" {
+			fileWords, err := LoadWordFile(rc.File)
+			if err != nil {
+				return nil, err
+			}
+			words = append(words, fileWords...)
+		}
+		return &WordListRule{Name: rc.Name, Source: words, Action: action}, nil
+	case "regex":
+		pattern, err := regexp.Compile(rc.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		return &RegexRule{Name: rc.Name, Pattern: pattern, Action: action}, nil
+	default:
+		return nil, fmt.Errorf("unknown rule type: %q", rc.Type)
+	}
+}
@@ -0,0 +1,93 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+)
+
+// Action is what a rule does with the content it matches.
+type Action string
+
+const (
+	// ActionMask replaces the matched content with Mask.
+	ActionMask Action = "mask"
+	// ActionReject refuses the whole chirp.
+	ActionReject Action = "reject"
+	// ActionFlag lets the chirp through unchanged. The match is only recorded
+	// with its decisions; nothing lists flagged chirps for review yet.
+	ActionFlag Action = "flag"
+)
+
+// Mask is the replacement used for masked content.
+const Mask = "****"
+
+func ParseAction(s string) (Action, error) {
+	switch action := Action(s); action {
+	case ActionMask, ActionReject, ActionFlag:
+		return action, nil
+	default:
+		return "", fmt.Errorf("unknown moderation action: %q", s)
+	}
+}
+
+// Decision records a single rule match.
+type Decision struct {
+	Rule   string `json:"rule"`
+	Action Action `json:"action"`
+	Match  string `json:"match"`
+}
+
+// Result is the outcome of moderating a piece of text. Text holds the text
+// with every masked match replaced.
+type Result struct {
+	Text      string
+	Decisions []Decision
+}
+
+func (r Result) Rejected() bool {
+	return r.has(ActionReject)
+}
+
+func (r Result) Flagged() bool {
+	return r.has(ActionFlag)
+}
+
+func (r Result) has(action Action) bool {
+	for _, decision := range r.Decisions {
+		if decision.Action == action {
+			return true
+		}
+	}
+	return false
+}
+
+// Moderator checks user content before it is stored.
+type Moderator interface {
+	Moderate(ctx context.Context, text string) (Result, error)
+}
+
+// Rule is a single step of a Chain. It returns the text with any masked
+// matches replaced, plus a decision for every match.
+type Rule interface {
+	Apply(ctx context.Context, text string) (string, []Decision, error)
+}
+
+// Chain is a Moderator that runs its rules in order, feeding the output of
+// each rule into the next. It stops at the first rule that rejects the text.
+type Chain []Rule
+
+func (c Chain) Moderate(ctx context.Context, text string) (Result, error) {
+	result := Result{Text: text}
+	for _, rule := range c {
+		cleaned, decisions, err := rule.Apply(ctx, result.Text)
+		if err != nil {
+			return Result{}, err
+		}
+		result.Text = cleaned
+		result.Decisions = append(result.Decisions, decisions...)
+		if result.Rejected() {
+			return result, nil
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,169 @@
+package moderation
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/kevinjimenez96/chirpy/internal/database"
+)
+
+func TestDefaultChain(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		wantText string
+	}{
+		{
+			name:     "Plain word",
+			text:     "I had a kerfuffle today",
+			wantText: "I had a **** today",
+		},
+		{
+			name:     "Trailing punctuation",
+			text:     "Kerfuffle! What a sharbert, honestly.",
+			wantText: "****! What a ****, honestly.",
+		},
+		{
+			name:     "Unicode spaces and quotes",
+			text:     "«fornax» again",
+			wantText: "«****» again",
+		},
+		{
+			name:     "Word inside another word",
+			text:     "kerfuffles are fine",
+			wantText: "kerfuffles are fine",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := DefaultChain().Moderate(context.Background(), tt.text)
+			if err != nil {
+				t.Fatalf("Moderate() error = %v", err)
+			}
+			if result.Text != tt.wantText {
+				t.Errorf("Moderate() text = %q, want %q", result.Text, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestChainActions(t *testing.T) {
+	chain := Chain{
+		&WordListRule{
+			Name:   "words",
+			Source: StaticWords{{Text: "kerfuffle"}, {Text: "fornax", Action: ActionReject}},
+			Action: ActionMask,
+		},
+		&RegexRule{
+			Name:    "links",
+			Pattern: regexp.MustCompile(`https?://\S+`),
+			Action:  ActionFlag,
+		},
+	}
+
+	tests := []struct {
+		name         string
+		text         string
+		wantText     string
+		wantRejected bool
+		wantFlagged  bool
+	}{
+		{
+			name:     "Clean",
+			text:     "hello there",
+			wantText: "hello there",
+		},
+		{
+			name:     "Masked",
+			text:     "what a kerfuffle",
+			wantText: "what a ****",
+		},
+		{
+			name:         "Rejected",
+			text:         "Fornax, kerfuffle",
+			wantText:     "Fornax, ****",
+			wantRejected: true,
+		},
+		{
+			name:        "Flagged",
+			text:        "see https://example.com",
+			wantText:    "see https://example.com",
+			wantFlagged: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := chain.Moderate(context.Background(), tt.text)
+			if err != nil {
+				t.Fatalf("Moderate() error = %v", err)
+			}
+			if result.Text != tt.wantText {
+				t.Errorf("Moderate() text = %q, want %q", result.Text, tt.wantText)
+			}
+			if result.Rejected() != tt.wantRejected {
+				t.Errorf("Rejected() = %v, want %v", result.Rejected(), tt.wantRejected)
+			}
+			if result.Flagged() != tt.wantFlagged {
+				t.Errorf("Flagged() = %v, want %v", result.Flagged(), tt.wantFlagged)
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	wordsPath := filepath.Join(dir, "words.txt")
+	os.WriteFile(wordsPath, []byte("# banned\nkerfuffle\nfornax reject\n"), 0o600)
+	configPath := filepath.Join(dir, "moderation.json")
+	os.WriteFile(configPath, []byte(`{"rules": [
+		{"type": "words", "name": "words", "action": "mask", "file": "`+wordsPath+`"},
+		{"type": "regex", "name": "shouting", "action": "flag", "pattern": "[A-Z]{5,}"}
+	]}`), 0o600)
+
+	chain, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	result, _ := chain.Moderate(context.Background(), "kerfuffle HELLO")
+	if result.Text != "**** HELLO" || !result.Flagged() {
+		t.Errorf("Moderate() = %+v, want masked and flagged", result)
+	}
+	result, _ = chain.Moderate(context.Background(), "fornax")
+	if !result.Rejected() {
+		t.Errorf("Moderate() = %+v, want rejected", result)
+	}
+
+	os.WriteFile(configPath, []byte(`{"rules": [{"type": "words", "name": "words", "action": "delete"}]}`), 0o600)
+	if _, err := LoadConfig(configPath); err == nil {
+		t.Errorf("LoadConfig() accepted an unknown action")
+	}
+}
+
+func TestStoreWords(t *testing.T) {
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+	rule := &WordListRule{Name: "banned-words", Source: StoreWords{Store: store}, Action: ActionMask}
+
+	text, _, _ := rule.Apply(ctx, "a wild gobbledygook appears")
+	if text != "a wild gobbledygook appears" {
+		t.Errorf("Apply() = %q before the word was banned", text)
+	}
+
+	store.UpsertBannedWord(ctx, database.UpsertBannedWordParams{Word: "gobbledygook", Action: string(ActionMask), CreatedBy: "test"})
+	text, _, _ = rule.Apply(ctx, "a wild Gobbledygook appears")
+	if text != "a wild **** appears" {
+		t.Errorf("Apply() = %q, want the new word masked", text)
+	}
+
+	store.UpsertBannedWord(ctx, database.UpsertBannedWordParams{Word: "gobbledygook", Action: string(ActionReject), CreatedBy: "test"})
+	_, decisions, _ := rule.Apply(ctx, "gobbledygook")
+	if len(decisions) != 1 || decisions[0].Action != ActionReject {
+		t.Errorf("Apply() decisions = %+v, want a reject", decisions)
+	}
+}
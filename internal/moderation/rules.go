@@ -0,0 +1,167 @@
+package moderation
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Token is a word found in a text. Start and End are byte offsets.
+type Token struct {
+	Text  string
+	Start int
+	End   int
+}
+
+// Tokenize splits text into words. A word is a run of letters, digits and
+// combining marks, so punctuation and any kind of Unicode space separate
+// words: "Kerfuffle!" and "kerfuffle," both yield "Kerfuffle"/"kerfuffle".
+func Tokenize(text string) []Token {
+	var tokens []Token
+	start := -1
+	for i, r := range text {
+		if isWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			tokens = append(tokens, Token{Text: text[start:i], Start: start, End: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, Token{Text: text[start:], Start: start, End: len(text)})
+	}
+	return tokens
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r))
+}
+
+// Word is an entry of a word list. An empty Action means the default action
+// of the rule using the list.
+type Word struct {
+	Text   string
+	Action Action
+}
+
+// WordSource provides the words of a WordListRule. It is consulted on every
+// Apply, so sources backed by a file or a table pick up changes right away.
+type WordSource interface {
+	Words(ctx context.Context) ([]Word, error)
+}
+
+// StaticWords is a fixed WordSource.
+type StaticWords []Word
+
+func (w StaticWords) Words(ctx context.Context) ([]Word, error) {
+	return w, nil
+}
+
+// LoadWordFile reads a word list with one word per line, optionally followed
+// by an action ("fornax reject"). Blank lines and lines starting with # are
+// ignored.
+func LoadWordFile(path string) (StaticWords, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var words StaticWords
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("%s:%d: expected a word and an optional action", path, lineNo)
+		}
+		word := Word{Text: fields[0]}
+		if len(fields) == 2 {
+			word.Action, err = ParseAction(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+			}
+		}
+		words = append(words, word)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return words, nil
+}
+
+// WordListRule matches whole words from a WordSource, ignoring case.
+type WordListRule struct {
+	Name   string
+	Source WordSource
+	Action Action
+}
+
+func (r *WordListRule) Apply(ctx context.Context, text string) (string, []Decision, error) {
+	words, err := r.Source.Words(ctx)
+	if err != nil {
+		return "", nil, fmt.Errorf("loading words for rule %s: %w", r.Name, err)
+	}
+
+	actions := make(map[string]Action, len(words))
+	for _, word := range words {
+		action := word.Action
+		if action == "" {
+			action = r.Action
+		}
+		actions[strings.ToLower(word.Text)] = action
+	}
+
+	var decisions []Decision
+	var cleaned strings.Builder
+	last := 0
+	for _, token := range Tokenize(text) {
+		action, ok := actions[strings.ToLower(token.Text)]
+		if !ok {
+			continue
+		}
+		decisions = append(decisions, Decision{Rule: r.Name, Action: action, Match: token.Text})
+		if action == ActionMask {
+			cleaned.WriteString(text[last:token.Start])
+			cleaned.WriteString(Mask)
+			last = token.End
+		}
+	}
+	cleaned.WriteString(text[last:])
+
+	return cleaned.String(), decisions, nil
+}
+
+// RegexRule matches a regular expression anywhere in the text.
+type RegexRule struct {
+	Name    string
+	Pattern *regexp.Regexp
+	Action  Action
+}
+
+func (r *RegexRule) Apply(ctx context.Context, text string) (string, []Decision, error) {
+	var decisions []Decision
+	for _, match := range r.Pattern.FindAllString(text, -1) {
+		if match == "" {
+			continue
+		}
+		decisions = append(decisions, Decision{Rule: r.Name, Action: r.Action, Match: match})
+	}
+	if r.Action == ActionMask {
+		text = r.Pattern.ReplaceAllLiteralString(text, Mask)
+	}
+	return text, decisions, nil
+}
@@ -0,0 +1,32 @@
+package moderation
+
+import (
+	"context"
+
+	"github.com/kevinjimenez96/chirpy/internal/database"
+)
+
+// BannedWordLister is the part of database.Store used by StoreWords.
+type BannedWordLister interface {
+	ListBannedWords(ctx context.Context) ([]database.BannedWord, error)
+}
+
+// StoreWords is a WordSource backed by the banned_words table. It reads the
+// table on every call, so changes made through the admin API apply to the
+// very next chirp.
+type StoreWords struct {
+	Store BannedWordLister
+}
+
+func (w StoreWords) Words(ctx context.Context) ([]Word, error) {
+	bannedWords, err := w.Store.ListBannedWords(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	words := make([]Word, 0, len(bannedWords))
+	for _, bannedWord := range bannedWords {
+		words = append(words, Word{Text: bannedWord.Word, Action: Action(bannedWord.Action)})
+	}
+	return words, nil
+}
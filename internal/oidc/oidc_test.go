@@ -0,0 +1,111 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/kevinjimenez96/chirpy/internal/auth"
+	"github.com/kevinjimenez96/chirpy/internal/oidc/oidctest"
+)
+
+const (
+	redirectURL = "https://chirpy.example/api/auth/test/callback"
+	verifier    = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+)
+
+func newTestProvider(t *testing.T) (*oidctest.Provider, *Provider) {
+	idp := oidctest.NewProvider(t)
+	return idp, NewProvider(Config{
+		Issuer:       idp.Issuer(),
+		ClientID:     oidctest.ClientID,
+		ClientSecret: oidctest.ClientSecret,
+		RedirectURL:  redirectURL,
+	})
+}
+
+// authorize visits the provider's authorization endpoint and returns the
+// code and state it redirects back with.
+func authorize(t *testing.T, p *Provider, state, nonce string) (string, string) {
+	t.Helper()
+	authURL, err := p.AuthCodeURL(context.Background(), state, nonce, auth.PKCEChallenge(verifier))
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("error visiting the authorization endpoint: %v", err)
+	}
+	resp.Body.Close()
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorization endpoint answered %d, %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func TestProviderExchange(t *testing.T) {
+	idp, p := newTestProvider(t)
+	user := oidctest.User{Subject: "1234", Email: "a@example.com", EmailVerified: true}
+	idp.SetUser(user)
+
+	code, state := authorize(t, p, "state", "nonce")
+	if state != "state" {
+		t.Errorf("state = %q, want %q", state, "state")
+	}
+	identity, err := p.Exchange(context.Background(), code, verifier, "nonce")
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	want := Identity{Subject: user.Subject, Email: user.Email, EmailVerified: true}
+	if identity != want {
+		t.Errorf("Exchange() = %+v, want %+v", identity, want)
+	}
+
+	if _, err := p.Exchange(context.Background(), code, verifier, "nonce"); err == nil {
+		t.Errorf("Exchange() redeemed a code twice")
+	}
+}
+
+func TestProviderExchangeErrors(t *testing.T) {
+	idp, p := newTestProvider(t)
+	idp.SetUser(oidctest.User{Subject: "1234"})
+
+	code, _ := authorize(t, p, "state", "nonce")
+	if _, err := p.Exchange(context.Background(), code, verifier, "other nonce"); err == nil {
+		t.Errorf("Exchange() accepted an ID token with another nonce")
+	}
+
+	code, _ = authorize(t, p, "state", "nonce")
+	if _, err := p.Exchange(context.Background(), code, verifier[1:]+"x", "nonce"); err == nil {
+		t.Errorf("Exchange() succeeded with the wrong code verifier")
+	}
+
+	other := NewProvider(Config{
+		Issuer:       idp.Issuer(),
+		ClientID:     "someone-else",
+		ClientSecret: oidctest.ClientSecret,
+		RedirectURL:  redirectURL,
+	})
+	if _, err := other.Exchange(context.Background(), "code", verifier, "nonce"); err == nil {
+		t.Errorf("Exchange() succeeded for an unknown client")
+	}
+}
+
+func TestProviderDiscoveryError(t *testing.T) {
+	idp := oidctest.NewProvider(t)
+	p := NewProvider(Config{
+		Issuer:      idp.Issuer() + "/tenant",
+		ClientID:    oidctest.ClientID,
+		RedirectURL: redirectURL,
+	})
+	if _, err := p.AuthCodeURL(context.Background(), "state", "nonce", auth.PKCEChallenge(verifier)); err == nil {
+		t.Errorf("AuthCodeURL() succeeded without a discovery document")
+	}
+}
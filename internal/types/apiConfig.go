@@ -1,19 +1,42 @@
 package types
 
 import (
+	"crypto/subtle"
+	"errors"
+	"log"
 	"net/http"
 	"sync/atomic"
+	"time"
 
 	"github.com/kevinjimenez96/chirpy/internal/auth"
 	"github.com/kevinjimenez96/chirpy/internal/database"
+	"github.com/kevinjimenez96/chirpy/internal/mailer"
+	"github.com/kevinjimenez96/chirpy/internal/moderation"
+	"github.com/kevinjimenez96/chirpy/internal/oidc"
 )
 
 type ApiConfig struct {
 	FileserverHits atomic.Int32
-	DbQueries      *database.Queries
+	DbQueries      database.Store
 	Platform       string
-	Secret         string
+	Keys           *auth.KeySet
+	Denylist       *auth.Denylist
 	PolkaKey       string
+	AdminKey       string
+	Moderator      moderation.Moderator
+	Mailer         mailer.Mailer
+	PasswordPolicy auth.PasswordPolicy
+	Passwords      *auth.PasswordHasher
+	// AccountThrottle and IPThrottle slow down password guessing on login,
+	// per email and per client address.
+	AccountThrottle *auth.Throttle
+	IPThrottle      *auth.Throttle
+	// DeletionGracePeriod is how long a deleted account can be restored
+	// before it is purged.
+	DeletionGracePeriod time.Duration
+	// OIDCProviders are the external identity providers users can log in
+	// with, by the name used in their URLs.
+	OIDCProviders map[string]*oidc.Provider
 }
 
 func (cfg *ApiConfig) MiddlewareMetricsInc(next http.Handler) http.Handler {
@@ -29,19 +52,128 @@ func (cfg *ApiConfig) MiddlewareAddConfig(handler func(http.ResponseWriter, *htt
 	})
 }
 
-func (cfg *ApiConfig) MiddlewareAuth(handler http.Handler) http.Handler {
+// MiddlewareAuth rejects requests without a valid access token, sent as
+// "Authorization: Bearer <token>", or personal API key, sent as
+// "Authorization: ApiKey <key>", and stores the caller in the request context,
+// see auth.PrincipalFromContext. API keys must hold scope.
+func (cfg *ApiConfig) MiddlewareAuth(scope string, handler http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		token, err := auth.GetBearerToken(r.Header)
+		principal, err := cfg.authenticate(r)
 		if err != nil {
 			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
 
-		_, err = auth.ValidateJWT(token, cfg.Secret)
+		if !principal.HasScope(scope) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		handler.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// MiddlewareOptionalAuth is MiddlewareAuth for routes anyone can call:
+// requests without an Authorization header are let through without a
+// principal, but credentials that are sent have to be valid.
+func (cfg *ApiConfig) MiddlewareOptionalAuth(scope string, handler http.Handler) http.Handler {
+	authenticated := cfg.MiddlewareAuth(scope, handler)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		authenticated.ServeHTTP(w, r)
+	})
+}
+
+// MiddlewareRequireRole only lets through requests whose access token carries
+// role, storing the caller in the request context like MiddlewareAuth.
+// Callers holding the admin API key, sent as "Authorization: ApiKey <key>",
+// are treated as admins; no principal is stored for them. Personal API keys
+// and OAuth clients are never let through.
+func (cfg *ApiConfig) MiddlewareRequireRole(role string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if role == auth.RoleAdmin && cfg.hasAdminKey(r) {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		principal, err := cfg.authenticate(r)
 		if err != nil {
 			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
-		handler.ServeHTTP(w, r)
+
+		if principal.IsScoped() || !principal.HasRole(role) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		handler.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
 	})
 }
+
+// authenticate validates the bearer token or personal API key of a request.
+func (cfg *ApiConfig) authenticate(r *http.Request) (auth.Principal, error) {
+	if key, err := auth.GetAPIKey(r.Header); err == nil {
+		return cfg.authenticateAPIKey(r, key)
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return auth.Principal{}, err
+	}
+
+	claims, err := auth.ParseJWT(token, cfg.Keys)
+	if err != nil {
+		return auth.Principal{}, err
+	}
+
+	principal := claims.Principal()
+	revoked, err := cfg.Denylist.IsRevoked(r.Context(), principal)
+	if err != nil {
+		return auth.Principal{}, err
+	}
+	if revoked {
+		return auth.Principal{}, errors.New("access token revoked")
+	}
+	return principal, nil
+}
+
+// authenticateAPIKey looks a personal API key up by its prefix and checks the
+// rest of it against the stored hash.
+func (cfg *ApiConfig) authenticateAPIKey(r *http.Request, key string) (auth.Principal, error) {
+	prefix, err := auth.ParseAPIKeyPrefix(key)
+	if err != nil {
+		return auth.Principal{}, err
+	}
+
+	apiKey, err := cfg.DbQueries.GetActiveApiKeyByPrefix(r.Context(), prefix)
+	if err != nil {
+		return auth.Principal{}, err
+	}
+	if subtle.ConstantTimeCompare([]byte(auth.HashAPIKey(key)), []byte(apiKey.KeyHash)) != 1 {
+		return auth.Principal{}, auth.ErrInvalidAPIKey
+	}
+
+	// last-used tracking is informational, so a failure doesn't fail the request
+	err = cfg.DbQueries.TouchApiKey(r.Context(), apiKey.ID)
+	if err != nil {
+		log.Printf("Error recording use of API key %s: %s", apiKey.ID, err)
+	}
+
+	return auth.Principal{
+		UserID:   apiKey.UserID,
+		APIKeyID: apiKey.ID,
+		Scopes:   apiKey.Scopes,
+	}, nil
+}
+
+// hasAdminKey reports whether the request carries the admin API key. It is
+// always false when no admin key is configured.
+func (cfg *ApiConfig) hasAdminKey(r *http.Request) bool {
+	key, err := auth.GetAPIKey(r.Header)
+	if err != nil || cfg.AdminKey == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(key), []byte(cfg.AdminKey)) == 1
+}
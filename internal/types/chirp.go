@@ -1,14 +1,43 @@
 package types
 
+import (
+	"github.com/google/uuid"
+	"github.com/kevinjimenez96/chirpy/internal/database"
+)
+
 type Chirp struct {
 	Body string `json:"body"`
 }
 
 type AddChirpReq struct {
 	Chirp
+	ReplyToID *uuid.UUID `json:"reply_to_id"`
 }
 
 type ValidateChirpResponse struct {
 	Valid       bool   `json:"valid"`
 	CleanedBody string `json:"cleaned_body"`
 }
+
+// ChirpRes is a chirp as listed to a caller. LikedByMe is only set for
+// authenticated callers. Deleted chirps only show up in threads, without
+// their body.
+type ChirpRes struct {
+	database.Chirp
+	LikedByMe *bool `json:"liked_by_me,omitempty"`
+	Deleted   bool  `json:"deleted,omitempty"`
+}
+
+type ChirpsPage struct {
+	Chirps     []ChirpRes `json:"chirps"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+// ChirpThread is the conversation around a chirp: the chirps it replies to,
+// root first, and a page of the replies under it, oldest first.
+type ChirpThread struct {
+	Ancestors  []ChirpRes `json:"ancestors"`
+	Chirp      ChirpRes   `json:"chirp"`
+	Replies    []ChirpRes `json:"replies"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
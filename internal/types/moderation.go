@@ -0,0 +1,6 @@
+package types
+
+type AddBannedWordReq struct {
+	Word   string `json:"word"`
+	Action string `json:"action"`
+}
@@ -0,0 +1,80 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateOAuthClientReq registers a third-party app. Confidential clients get
+// a secret; public ones, like mobile and browser apps, rely on PKCE alone.
+type CreateOAuthClientReq struct {
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirect_uris"`
+	Confidential bool     `json:"confidential"`
+}
+
+// OAuthClientRes describes a registered client. Secret is only set in the
+// response that registers it.
+type OAuthClientRes struct {
+	ID           uuid.UUID `json:"client_id"`
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Confidential bool      `json:"confidential"`
+	Secret       string    `json:"client_secret,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// OAuthAuthorizeReq carries the parameters of an authorization request, read
+// from the query string by GET /api/oauth/authorize and from the body by
+// POST /api/oauth/authorize, which also carries the user's decision.
+type OAuthAuthorizeReq struct {
+	ResponseType        string `json:"response_type"`
+	ClientID            string `json:"client_id"`
+	RedirectURI         string `json:"redirect_uri"`
+	Scope               string `json:"scope"`
+	State               string `json:"state"`
+	CodeChallenge       string `json:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method"`
+	Approve             bool   `json:"approve"`
+}
+
+// OAuthConsentRes is what the user is asked to approve.
+type OAuthConsentRes struct {
+	ClientID    uuid.UUID `json:"client_id"`
+	ClientName  string    `json:"client_name"`
+	RedirectURI string    `json:"redirect_uri"`
+	Scopes      []string  `json:"scopes"`
+}
+
+// OAuthRedirectRes tells the user's browser where to go back to the client.
+type OAuthRedirectRes struct {
+	RedirectTo string `json:"redirect_to"`
+}
+
+type OAuthTokenRes struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+}
+
+// OAuthIntrospectRes follows RFC 7662. Only Active is set for tokens that
+// are not active.
+type OAuthIntrospectRes struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+}
+
+// OAuthErrorRes is the error format of RFC 6749. RedirectTo is set when the
+// error should be passed on to the client.
+type OAuthErrorRes struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+	RedirectTo       string `json:"redirect_to,omitempty"`
+}
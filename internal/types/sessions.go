@@ -0,0 +1,17 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SessionRes struct {
+	ID         uuid.UUID  `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+	ClientID   *uuid.UUID `json:"client_id"`
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/kevinjimenez96/chirpy/internal/auth"
 )
 
 type CreateUserReq struct {
@@ -11,9 +12,12 @@ type CreateUserReq struct {
 	Password string `json:"password"`
 }
 
+// UpdateUserReq only changes the fields that are present. Changing the email
+// or the password requires CurrentPassword.
 type UpdateUserReq struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email           *string `json:"email"`
+	Password        *string `json:"password"`
+	CurrentPassword string  `json:"current_password"`
 }
 
 type LoginUserReq struct {
@@ -29,8 +33,62 @@ type LoginUserRes struct {
 	Token        string    `json:"token"`
 	RefreshToken string    `json:"refresh_token"`
 	IsChirpyRed  bool      `json:"is_chirpy_red"`
+	Role         string    `json:"role"`
+	IsVerified   bool      `json:"is_verified"`
 }
 
 type RefreshTokenRes struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type UpdateUserRoleReq struct {
+	Role string `json:"role"`
+}
+
+type VerifyEmailReq struct {
 	Token string `json:"token"`
 }
+
+type ForgotPasswordReq struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordReq struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type PasswordPolicyErrorRes struct {
+	Error      string                 `json:"error"`
+	Violations []auth.PolicyViolation `json:"violations"`
+}
+
+type EnrollTOTPRes struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type ConfirmTOTPReq struct {
+	Code string `json:"code"`
+}
+
+// ConfirmTOTPRes carries the recovery codes, which are only ever shown once.
+type ConfirmTOTPRes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorChallengeRes is returned by login instead of LoginUserRes when the
+// user has 2FA enabled.
+type TwoFactorChallengeRes struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+}
+
+// LoginTwoFactorReq completes a login with either a TOTP code or a recovery
+// code.
+type LoginTwoFactorReq struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+}
@@ -1,33 +1,227 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/kevinjimenez96/chirpy/internal/auth"
 	"github.com/kevinjimenez96/chirpy/internal/database"
 	"github.com/kevinjimenez96/chirpy/internal/handlers"
+	"github.com/kevinjimenez96/chirpy/internal/mailer"
+	"github.com/kevinjimenez96/chirpy/internal/moderation"
+	"github.com/kevinjimenez96/chirpy/internal/oidc"
 	"github.com/kevinjimenez96/chirpy/internal/types"
+	"golang.org/x/crypto/bcrypt"
 
 	_ "github.com/lib/pq"
 )
 
+// denylistCacheTTL bounds how long a revocation made by another instance
+// takes to reach this one.
+const denylistCacheTTL = 30 * time.Second
+
+// purgeRevokedAccessTokens periodically drops denylist entries for tokens
+// that have expired anyway.
+func purgeRevokedAccessTokens(store database.Store) {
+	for range time.Tick(time.Hour) {
+		if err := store.DeleteExpiredRevokedAccessTokens(context.Background()); err != nil {
+			log.Printf("Error purging revoked access tokens: %s", err)
+		}
+	}
+}
+
+// Failed logins are throttled per account and, more loosely, per client
+// address, which may be shared by many users.
+var (
+	accountThrottlePolicy = auth.ThrottlePolicy{
+		FreeAttempts: 3,
+		BaseDelay:    time.Second,
+		MaxDelay:     time.Minute,
+		LockoutAfter: 10,
+		Lockout:      15 * time.Minute,
+	}
+	ipThrottlePolicy = auth.ThrottlePolicy{
+		FreeAttempts: 10,
+		BaseDelay:    time.Second,
+		MaxDelay:     time.Minute,
+		LockoutAfter: 100,
+		Lockout:      15 * time.Minute,
+	}
+)
+
+// defaultDeletionGracePeriod is how long a deleted account can be restored
+// unless ACCOUNT_DELETION_GRACE_PERIOD says otherwise.
+const defaultDeletionGracePeriod = 30 * 24 * time.Hour
+
+// purgeDeletedUsers periodically hard-deletes accounts deleted more than
+// gracePeriod ago; their chirps and tokens go with them through ON DELETE
+// CASCADE.
+func purgeDeletedUsers(store database.Store, gracePeriod time.Duration) {
+	for range time.Tick(time.Hour) {
+		deletedBefore := sql.NullTime{Time: time.Now().UTC().Add(-gracePeriod), Valid: true}
+		purged, err := store.PurgeDeletedUsers(context.Background(), deletedBefore)
+		if err != nil {
+			log.Printf("Error purging deleted users: %s", err)
+			continue
+		}
+		if purged > 0 {
+			log.Printf("Purged %d deleted users", purged)
+		}
+	}
+}
+
 func main() {
 	godotenv.Load()
-	dbURL := os.Getenv("DB_URL")
-	db, err := sql.Open("postgres", dbURL)
 
-	if err != nil {
-		log.Fatal("Error opening db connection.")
+	// the in-memory store loses everything on restart, so it has to be asked
+	// for by name rather than be what a missing DB_URL ends up with
+	var store database.Store
+	switch storeKind := os.Getenv("STORE"); storeKind {
+	case "memory":
+		log.Println("Using an in-memory store. Data will be lost on restart.")
+		store = database.NewMemoryStore()
+	case "", "postgres":
+		dbURL := os.Getenv("DB_URL")
+		if dbURL == "" {
+			log.Fatal("DB_URL is not set. Set STORE=memory to run without a database.")
+		}
+		db, err := sql.Open("postgres", dbURL)
+		if err != nil {
+			log.Fatal("Error opening db connection.")
+		}
+		store = database.NewPostgresStore(db)
+	default:
+		log.Fatalf("Unknown STORE %q, want postgres or memory", storeKind)
+	}
+
+	chain := moderation.DefaultChain()
+	if moderationConfig := os.Getenv("MODERATION_CONFIG"); moderationConfig != "" {
+		var err error
+		chain, err = moderation.LoadConfig(moderationConfig)
+		if err != nil {
+			log.Fatalf("Error loading moderation config: %s", err)
+		}
+	}
+	// the admin-managed list is read on every chirp, so it applies right away
+	chain = append(chain, &moderation.WordListRule{
+		Name:   "banned-words",
+		Source: moderation.StoreWords{Store: store},
+		Action: moderation.ActionMask,
+	})
+
+	keys := auth.NewHMACKeySet(os.Getenv("SECRET"))
+	if signingKeyFile := os.Getenv("JWT_SIGNING_KEY_FILE"); signingKeyFile != "" {
+		var verificationKeyFiles []string
+		if files := os.Getenv("JWT_VERIFICATION_KEY_FILES"); files != "" {
+			verificationKeyFiles = strings.Split(files, ",")
+		}
+		var err error
+		keys, err = auth.LoadKeySet(signingKeyFile, verificationKeyFiles)
+		if err != nil {
+			log.Fatalf("Error loading JWT keys: %s", err)
+		}
+	}
+
+	// without an SMTP server, emails are written to MAIL_FILE or the log
+	mailFrom := os.Getenv("MAIL_FROM")
+	if mailFrom == "" {
+		mailFrom = "chirpy@localhost"
+	}
+	var mail mailer.Mailer = &mailer.WriterMailer{W: log.Writer(), From: mailFrom}
+	if smtpAddr := os.Getenv("SMTP_ADDR"); smtpAddr != "" {
+		mail = &mailer.SMTPMailer{
+			Addr:     smtpAddr,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     mailFrom,
+		}
+	} else if mailFile := os.Getenv("MAIL_FILE"); mailFile != "" {
+		f, err := os.OpenFile(mailFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			log.Fatalf("Error opening mail file: %s", err)
+		}
+		defer f.Close()
+		mail = &mailer.WriterMailer{W: f, From: mailFrom}
+	}
+
+	passwordPolicy := auth.DefaultPasswordPolicy()
+	if passwordPolicyFile := os.Getenv("PASSWORD_POLICY"); passwordPolicyFile != "" {
+		var err error
+		passwordPolicy, err = auth.LoadPasswordPolicy(passwordPolicyFile)
+		if err != nil {
+			log.Fatalf("Error loading password policy: %s", err)
+		}
+	}
+
+	// existing hashes of another scheme or cost are upgraded on login
+	passwords := auth.DefaultPasswordHasher()
+	if scheme := os.Getenv("PASSWORD_HASH_SCHEME"); scheme != "" {
+		if scheme != auth.SchemeBcrypt && scheme != auth.SchemeArgon2id {
+			log.Fatalf("Unknown PASSWORD_HASH_SCHEME %q", scheme)
+		}
+		passwords.Scheme = scheme
+	}
+	if bcryptCost := os.Getenv("BCRYPT_COST"); bcryptCost != "" {
+		var err error
+		passwords.BcryptCost, err = strconv.Atoi(bcryptCost)
+		if err != nil || passwords.BcryptCost < bcrypt.MinCost || passwords.BcryptCost > bcrypt.MaxCost {
+			log.Fatalf("BCRYPT_COST must be a number between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	}
+
+	deletionGracePeriod := defaultDeletionGracePeriod
+	if gracePeriod := os.Getenv("ACCOUNT_DELETION_GRACE_PERIOD"); gracePeriod != "" {
+		var err error
+		deletionGracePeriod, err = time.ParseDuration(gracePeriod)
+		if err != nil {
+			log.Fatalf("Error parsing ACCOUNT_DELETION_GRACE_PERIOD: %s", err)
+		}
+	}
+
+	// each provider named in OIDC_PROVIDERS is configured by OIDC_<NAME>_*
+	// variables
+	oidcProviders := make(map[string]*oidc.Provider)
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		config := oidc.Config{
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+		}
+		if config.Issuer == "" || config.ClientID == "" || config.RedirectURL == "" {
+			log.Fatalf("OIDC provider %q needs %sISSUER, %sCLIENT_ID and %sREDIRECT_URL", name, prefix, prefix, prefix)
+		}
+		oidcProviders[name] = oidc.NewProvider(config)
 	}
 
 	var cfg = &types.ApiConfig{
-		DbQueries: database.New(db),
+		DbQueries: store,
 		Platform:  os.Getenv("PLATFORM"),
-		Secret:    os.Getenv("SECRET"),
+		Keys:      keys,
+		Denylist:  auth.NewDenylist(store, denylistCacheTTL),
 		PolkaKey:  os.Getenv("POLKA_KEY"),
+		AdminKey:  os.Getenv("ADMIN_API_KEY"),
+		Moderator: chain,
+		Mailer:    mail,
+
+		PasswordPolicy:      passwordPolicy,
+		Passwords:           passwords,
+		AccountThrottle:     auth.NewThrottle(accountThrottlePolicy),
+		IPThrottle:          auth.NewThrottle(ipThrottlePolicy),
+		DeletionGracePeriod: deletionGracePeriod,
+		OIDCProviders:       oidcProviders,
 	}
 
 	port := "8080"
@@ -35,25 +229,11 @@ func main() {
 
 	serveMux := http.NewServeMux()
 
-	serveMux.Handle("GET /admin/metrics", cfg.MiddlewareAddConfig(handlers.MetricsHandler))
-	serveMux.Handle("POST /admin/reset", cfg.MiddlewareAddConfig(handlers.ResetHandler))
-	serveMux.HandleFunc("GET /api/healthz", handlers.HealthzHandler)
-
 	serveMux.Handle("/app/", http.StripPrefix("/app", cfg.MiddlewareMetricsInc(http.FileServer(filepathRoot))))
+	handlers.Register(serveMux, cfg)
 
-	serveMux.Handle("GET /api/chirps", cfg.MiddlewareAddConfig(handlers.GetAllChirps))
-	serveMux.Handle("GET /api/chirps/{id}", cfg.MiddlewareAddConfig(handlers.GetChirpById))
-	serveMux.Handle("POST /api/chirps", cfg.MiddlewareAuth(cfg.MiddlewareAddConfig(handlers.AddChirp)))
-	serveMux.Handle("DELETE /api/chirps/{id}", cfg.MiddlewareAuth(cfg.MiddlewareAddConfig(handlers.DeleteChirpByIdHandler)))
-
-	serveMux.Handle("POST /api/users", cfg.MiddlewareAddConfig(handlers.AddUserHandler))
-	serveMux.Handle("PUT /api/users", cfg.MiddlewareAuth(cfg.MiddlewareAddConfig(handlers.UpdateUserHandler)))
-
-	serveMux.Handle("POST /api/login", cfg.MiddlewareAddConfig(handlers.LoginHandler))
-	serveMux.Handle("POST /api/refresh", cfg.MiddlewareAddConfig(handlers.RefreshTokenHandler))
-	serveMux.Handle("POST /api/revoke", cfg.MiddlewareAddConfig(handlers.RevokeHandler))
-
-	serveMux.Handle("POST /api/polka/webhooks", cfg.MiddlewareAddConfig(handlers.PolkaWebHook))
+	go purgeRevokedAccessTokens(store)
+	go purgeDeletedUsers(store, deletionGracePeriod)
 
 	srv := &http.Server{
 		Handler: serveMux,
@@ -0,0 +1,34 @@
+-- name: CreateApiKey :one
+INSERT INTO api_keys (id, user_id, name, prefix, key_hash, scopes, created_at)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    NOW()
+)
+RETURNING *;
+
+-- name: GetActiveApiKeyByPrefix :one
+-- Keys of banned or deleted users don't work either.
+SELECT api_keys.* FROM api_keys
+JOIN users ON users.id = api_keys.user_id
+WHERE api_keys.prefix = $1 AND api_keys.revoked_at IS NULL
+    AND users.banned_at IS NULL AND users.deleted_at IS NULL;
+
+-- name: ListActiveApiKeysByUser :many
+SELECT * FROM api_keys
+WHERE user_id = $1 AND revoked_at IS NULL
+ORDER BY created_at DESC;
+
+-- name: RevokeApiKeyForUser :one
+UPDATE api_keys SET revoked_at = NOW()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+RETURNING *;
+
+-- name: TouchApiKey :exec
+-- Written at most once a minute, since keys are used on every request.
+UPDATE api_keys SET last_used_at = NOW()
+WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - make_interval(mins => 1));
@@ -0,0 +1,36 @@
+-- name: ListBannedWords :many
+SELECT * FROM banned_words
+ORDER BY word;
+
+-- name: UpsertBannedWord :one
+INSERT INTO banned_words (word, action, created_at, updated_at, created_by)
+VALUES (
+    $1,
+    $2,
+    NOW(),
+    NOW(),
+    $3
+)
+ON CONFLICT (word) DO UPDATE SET action = EXCLUDED.action, updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteBannedWord :one
+DELETE FROM banned_words
+WHERE word = $1
+RETURNING *;
+
+-- name: CreateBannedWordChange :one
+INSERT INTO banned_word_changes (id, word, change, action, changed_by, changed_at)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    $2,
+    $3,
+    $4,
+    NOW()
+)
+RETURNING *;
+
+-- name: ListBannedWordChanges :many
+SELECT * FROM banned_word_changes
+ORDER BY changed_at DESC;
\ No newline at end of file
@@ -0,0 +1,14 @@
+-- name: LikeChirp :exec
+-- Liking a chirp twice is a no-op.
+INSERT INTO chirp_likes (chirp_id, user_id, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT DO NOTHING;
+
+-- name: ListLikedChirpIDs :many
+-- Returns which of chirp_ids the user liked.
+SELECT chirp_id FROM chirp_likes
+WHERE user_id = @user_id AND chirp_id = ANY(@chirp_ids::uuid[]);
+
+-- name: UnlikeChirp :exec
+DELETE FROM chirp_likes
+WHERE chirp_id = $1 AND user_id = $2;
@@ -0,0 +1,16 @@
+-- name: CreateChirpModerationDecision :one
+INSERT INTO chirp_moderation_decisions (id, created_at, chirp_id, rule, action, matched_text)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4
+)
+RETURNING *;
+
+-- name: GetChirpModerationDecisions :many
+SELECT * FROM chirp_moderation_decisions
+WHERE chirp_id = $1
+ORDER BY created_at;
\ No newline at end of file
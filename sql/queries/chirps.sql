@@ -1,33 +1,115 @@
 -- name: CreateChirp :one
 
-INSERT INTO chirps (id, created_at, updated_at, user_id, body)
-VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2) RETURNING *;
+INSERT INTO chirps (id, created_at, updated_at, user_id, body, reply_to_id)
+VALUES (gen_random_uuid(), NOW(), NOW(), $1, $2, $3) RETURNING *;
 
 -- name: GetAllChirps :many
 
 SELECT *
 FROM chirps
+-- chirps of deleted accounts stay hidden until the account is restored or purged
+WHERE deleted_at IS NULL AND user_id IN (SELECT id FROM users WHERE deleted_at IS NULL) AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (@sort::text = 'ASC' AND (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+    OR (@sort::text = 'DESC' AND (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+    OR (@sort::text = 'LIKES' AND (like_count, created_at, id) < (sqlc.narg('cursor_like_count')::integer, sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+)
 ORDER BY
     CASE WHEN @sort::text = 'ASC' THEN created_at END ASC,
-    CASE WHEN @sort::text = 'DESC' THEN created_at END DESC;
+    CASE WHEN @sort::text = 'ASC' THEN id END ASC,
+    CASE WHEN @sort::text = 'DESC' THEN created_at END DESC,
+    CASE WHEN @sort::text = 'DESC' THEN id END DESC,
+    CASE WHEN @sort::text = 'LIKES' THEN like_count END DESC,
+    CASE WHEN @sort::text = 'LIKES' THEN created_at END DESC,
+    CASE WHEN @sort::text = 'LIKES' THEN id END DESC
+LIMIT @row_limit;
 
 -- name: GetAllChirpsByAuthor :many
 
 SELECT *
 FROM chirps
-WHERE user_id = $1
+WHERE user_id = $1 AND deleted_at IS NULL AND user_id IN (SELECT id FROM users WHERE deleted_at IS NULL) AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (@sort::text = 'ASC' AND (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+    OR (@sort::text = 'DESC' AND (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+    OR (@sort::text = 'LIKES' AND (like_count, created_at, id) < (sqlc.narg('cursor_like_count')::integer, sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+)
 ORDER BY
     CASE WHEN @sort::text = 'ASC' THEN created_at END ASC,
-    CASE WHEN @sort::text = 'DESC' THEN created_at END DESC;
+    CASE WHEN @sort::text = 'ASC' THEN id END ASC,
+    CASE WHEN @sort::text = 'DESC' THEN created_at END DESC,
+    CASE WHEN @sort::text = 'DESC' THEN id END DESC,
+    CASE WHEN @sort::text = 'LIKES' THEN like_count END DESC,
+    CASE WHEN @sort::text = 'LIKES' THEN created_at END DESC,
+    CASE WHEN @sort::text = 'LIKES' THEN id END DESC
+LIMIT @row_limit;
 
 -- name: GetChirpById :one
 
 SELECT *
 FROM chirps
-WHERE id = $1;
+WHERE id = $1 AND deleted_at IS NULL AND user_id IN (SELECT id FROM users WHERE deleted_at IS NULL);
 
 -- name: DeleteChirpById :one
+-- Chirps with replies are kept, see MarkChirpDeleted.
 DELETE
 FROM chirps
 WHERE id = $1 AND user_id = $2
-RETURNING id;
\ No newline at end of file
+    AND NOT EXISTS (SELECT 1 FROM chirps replies WHERE replies.reply_to_id = chirps.id)
+RETURNING id;
+
+-- name: MarkChirpDeleted :one
+UPDATE chirps SET body = '', deleted_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+RETURNING id;
+
+-- name: GetChirpAncestors :many
+-- Returns the chirps a chirp replies to, root first. Deleted chirps are
+-- included so the chain stays whole, but those of deleted accounts aren't.
+WITH RECURSIVE ancestors (id, reply_to_id, depth) AS (
+    SELECT parent.id, parent.reply_to_id, 1
+    FROM chirps parent
+    JOIN chirps child ON child.reply_to_id = parent.id
+    WHERE child.id = @id
+    UNION ALL
+    SELECT parent.id, parent.reply_to_id, ancestors.depth + 1
+    FROM chirps parent
+    JOIN ancestors ON ancestors.reply_to_id = parent.id
+)
+SELECT chirps.*
+FROM chirps
+JOIN ancestors ON ancestors.id = chirps.id
+WHERE chirps.user_id IN (SELECT id FROM users WHERE deleted_at IS NULL)
+ORDER BY ancestors.depth DESC;
+
+-- name: GetChirpDescendants :many
+-- Returns the replies to a chirp and their replies in turn, oldest first.
+-- Deleted chirps are included like in GetChirpAncestors.
+WITH RECURSIVE descendants (id) AS (
+    SELECT replies.id
+    FROM chirps replies
+    WHERE replies.reply_to_id = @id
+    UNION ALL
+    SELECT replies.id
+    FROM chirps replies
+    JOIN descendants ON replies.reply_to_id = descendants.id
+)
+SELECT chirps.*
+FROM chirps
+JOIN descendants ON descendants.id = chirps.id
+WHERE chirps.user_id IN (SELECT id FROM users WHERE deleted_at IS NULL) AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (chirps.created_at, chirps.id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY chirps.created_at, chirps.id
+LIMIT @row_limit;
+
+-- name: SearchChirps :many
+SELECT chirps.*, ts_rank(chirps.body_tsv, query)::real AS rank
+FROM chirps, to_tsquery('english', @query::text) query
+WHERE chirps.body_tsv @@ query
+    AND chirps.deleted_at IS NULL
+    AND (sqlc.narg('author_id')::uuid IS NULL OR chirps.user_id = sqlc.narg('author_id')::uuid)
+    AND chirps.user_id IN (SELECT id FROM users WHERE deleted_at IS NULL)
+ORDER BY rank DESC, chirps.created_at DESC, chirps.id DESC
+LIMIT @row_limit;
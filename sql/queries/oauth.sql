@@ -0,0 +1,50 @@
+-- name: CreateOauthClient :one
+INSERT INTO oauth_clients (id, owner_id, name, redirect_uris, secret_hash, created_at)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    $2,
+    $3,
+    $4,
+    NOW()
+)
+RETURNING *;
+
+-- name: GetOauthClient :one
+SELECT * FROM oauth_clients
+WHERE id = $1;
+
+-- name: ListOauthClientsByOwner :many
+SELECT * FROM oauth_clients
+WHERE owner_id = $1
+ORDER BY created_at DESC;
+
+-- name: DeleteOauthClientForOwner :one
+DELETE FROM oauth_clients
+WHERE id = $1 AND owner_id = $2
+RETURNING *;
+
+-- name: CreateOauthAuthorizationCode :one
+INSERT INTO oauth_authorization_codes (code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, family_id, created_at, expires_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    NOW(),
+    NOW() + make_interval(mins => 5)
+)
+RETURNING *;
+
+-- name: UseOauthAuthorizationCode :one
+-- Nothing is returned for codes that expired or were already used.
+UPDATE oauth_authorization_codes SET used_at = NOW()
+WHERE code_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+RETURNING *;
+
+-- name: GetOauthAuthorizationCode :one
+SELECT * FROM oauth_authorization_codes
+WHERE code_hash = $1;
@@ -0,0 +1,18 @@
+-- name: CreatePasswordResetToken :one
+INSERT INTO password_reset_tokens (token_hash, user_id, created_at, expires_at)
+VALUES (
+    $1,
+    $2,
+    NOW(),
+    NOW() + make_interval(mins => 30)
+)
+RETURNING *;
+
+-- name: UsePasswordResetToken :one
+UPDATE password_reset_tokens SET used_at = NOW()
+WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+RETURNING *;
+
+-- name: InvalidatePasswordResetTokensForUser :exec
+UPDATE password_reset_tokens SET used_at = NOW()
+WHERE user_id = $1 AND used_at IS NULL;
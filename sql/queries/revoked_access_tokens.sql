@@ -0,0 +1,33 @@
+-- name: IsAccessTokenRevoked :one
+SELECT EXISTS (
+    SELECT 1 FROM revoked_access_tokens
+    WHERE id = $1
+);
+
+-- name: RevokeAccessTokensForFamily :many
+INSERT INTO revoked_access_tokens (id, user_id, expires_at, revoked_at)
+SELECT access_token_id, user_id, access_token_expires_at, NOW()
+FROM refresh_tokens
+WHERE family_id = $1 AND access_token_id IS NOT NULL AND access_token_expires_at > NOW()
+ON CONFLICT (id) DO NOTHING
+RETURNING *;
+
+-- name: RevokeAccessTokensForUser :many
+INSERT INTO revoked_access_tokens (id, user_id, expires_at, revoked_at)
+SELECT access_token_id, user_id, access_token_expires_at, NOW()
+FROM refresh_tokens
+WHERE user_id = $1 AND access_token_id IS NOT NULL AND access_token_expires_at > NOW()
+ON CONFLICT (id) DO NOTHING
+RETURNING *;
+
+-- name: DeleteExpiredRevokedAccessTokens :exec
+DELETE FROM revoked_access_tokens
+WHERE expires_at <= NOW();
+
+-- name: RevokeAccessTokensForClient :many
+INSERT INTO revoked_access_tokens (id, user_id, expires_at, revoked_at)
+SELECT access_token_id, user_id, access_token_expires_at, NOW()
+FROM refresh_tokens
+WHERE client_id = $1 AND access_token_id IS NOT NULL AND access_token_expires_at > NOW()
+ON CONFLICT (id) DO NOTHING
+RETURNING *;
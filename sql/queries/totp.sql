@@ -0,0 +1,35 @@
+-- name: UpsertUserTotp :one
+-- Starts an enrollment, replacing any unconfirmed one. Nothing is returned
+-- when 2FA is already enabled.
+INSERT INTO user_totp (user_id, secret, created_at)
+VALUES ($1, $2, NOW())
+ON CONFLICT (user_id) DO UPDATE
+SET secret = EXCLUDED.secret, created_at = EXCLUDED.created_at, last_used_step = 0
+WHERE user_totp.confirmed_at IS NULL
+RETURNING *;
+
+-- name: GetUserTotp :one
+SELECT * FROM user_totp WHERE user_id = $1;
+
+-- name: ConfirmUserTotp :one
+UPDATE user_totp SET confirmed_at = NOW(), last_used_step = $2
+WHERE user_id = $1 AND confirmed_at IS NULL
+RETURNING *;
+
+-- name: UseTotpStep :one
+-- Nothing is returned when a code of this or a later step was already used.
+UPDATE user_totp SET last_used_step = $2
+WHERE user_id = $1 AND confirmed_at IS NOT NULL AND last_used_step < $2
+RETURNING *;
+
+-- name: CreateTotpRecoveryCodes :exec
+INSERT INTO totp_recovery_codes (code_hash, user_id, created_at)
+SELECT unnest(@code_hashes::text[]), @user_id::uuid, NOW();
+
+-- name: DeleteTotpRecoveryCodesForUser :exec
+DELETE FROM totp_recovery_codes WHERE user_id = $1;
+
+-- name: UseTotpRecoveryCode :one
+UPDATE totp_recovery_codes SET used_at = NOW()
+WHERE code_hash = $1 AND user_id = $2 AND used_at IS NULL
+RETURNING *;
@@ -0,0 +1,19 @@
+-- name: CreateUserIdentity :one
+INSERT INTO user_identities (provider, subject, user_id, email, created_at, last_login_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    NOW(),
+    NOW()
+)
+RETURNING *;
+
+-- name: GetUserIdentity :one
+SELECT * FROM user_identities
+WHERE provider = $1 AND subject = $2;
+
+-- name: TouchUserIdentity :exec
+UPDATE user_identities SET last_login_at = NOW()
+WHERE provider = $1 AND subject = $2;
@@ -13,8 +13,17 @@ RETURNING *;
 SELECT * FROM users
 WHERE email = $1;
 
+-- name: GetUserById :one
+SELECT * FROM users
+WHERE id = $1;
+
 -- name: UpdateUser :one
-UPDATE users SET email = $2, hashed_password= $3, updated_at = NOW()
+-- a new email address has to be verified again
+UPDATE users SET
+    email = $2,
+    hashed_password= $3,
+    verified_at = CASE WHEN email = $2 THEN verified_at END,
+    updated_at = NOW()
 WHERE id = $1
 RETURNING *;
 
@@ -25,4 +34,45 @@ DELETE FROM users;
 -- name: UpdateUserIsChirpyRedById :one
 UPDATE users SET is_chirpy_red = $2, updated_at = NOW()
 WHERE id = $1
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: UpdateUserRole :one
+UPDATE users SET role = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: BanUser :one
+UPDATE users SET banned_at = NOW(), updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: UnbanUser :one
+UPDATE users SET banned_at = NULL, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: VerifyUserEmail :one
+-- Addresses are only verified once, so a verification token can't be reused.
+UPDATE users SET verified_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND email = $2 AND verified_at IS NULL
+RETURNING *;
+
+-- name: UpdateUserPassword :one
+UPDATE users SET hashed_password = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+
+-- name: SoftDeleteUser :one
+UPDATE users SET deleted_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: RestoreUser :one
+UPDATE users SET deleted_at = NULL, updated_at = NOW()
+WHERE id = $1 AND deleted_at > @deleted_after
+RETURNING *;
+
+-- name: PurgeDeletedUsers :execrows
+DELETE FROM users
+WHERE deleted_at <= @deleted_before;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE chirp_moderation_decisions(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    rule TEXT NOT NULL,
+    action TEXT NOT NULL,
+    matched_text TEXT NOT NULL
+);
+
+CREATE INDEX chirp_moderation_decisions_chirp_id_idx ON chirp_moderation_decisions (chirp_id);
+
+-- +goose Down
+DROP TABLE chirp_moderation_decisions;
@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE banned_words(
+    word TEXT PRIMARY KEY,
+    action TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    created_by TEXT NOT NULL
+);
+
+CREATE TABLE banned_word_changes(
+    id UUID PRIMARY KEY,
+    word TEXT NOT NULL,
+    change TEXT NOT NULL,
+    action TEXT NOT NULL,
+    changed_by TEXT NOT NULL,
+    changed_at TIMESTAMP NOT NULL
+);
+
+-- +goose Down
+DROP TABLE banned_word_changes;
+DROP TABLE banned_words;
@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE refresh_tokens
+RENAME COLUMN token TO token_hash;
+
+-- hash the existing tokens in place so active sessions keep working
+UPDATE refresh_tokens
+SET token_hash = encode(sha256(convert_to(token_hash, 'UTF8')), 'hex');
+
+UPDATE refresh_tokens
+SET replaced_by = encode(sha256(convert_to(replaced_by, 'UTF8')), 'hex')
+WHERE replaced_by IS NOT NULL;
+
+-- +goose Down
+-- hashes can't be turned back into tokens, so every session is dropped
+DELETE FROM refresh_tokens;
+
+ALTER TABLE refresh_tokens
+RENAME COLUMN token_hash TO token;
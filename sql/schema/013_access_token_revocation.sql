@@ -0,0 +1,22 @@
+-- +goose Up
+-- the access token issued alongside each refresh token, so logging out can
+-- revoke it too
+ALTER TABLE refresh_tokens
+ADD COLUMN access_token_id UUID,
+ADD COLUMN access_token_expires_at TIMESTAMP;
+
+CREATE TABLE revoked_access_tokens (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX revoked_access_tokens_expires_at_idx ON revoked_access_tokens (expires_at);
+
+-- +goose Down
+DROP TABLE revoked_access_tokens;
+
+ALTER TABLE refresh_tokens
+DROP COLUMN access_token_expires_at,
+DROP COLUMN access_token_id;
@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN verified_at TIMESTAMP;
+
+-- accounts created before verification existed stay able to post
+UPDATE users SET verified_at = created_at;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN verified_at;
@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE user_totp(
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    confirmed_at TIMESTAMP,
+    -- the last time step a code was accepted for, so codes can't be replayed
+    last_used_step BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE totp_recovery_codes(
+    code_hash TEXT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE totp_recovery_codes;
+DROP TABLE user_totp;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- Accounts at external OpenID Connect providers that users log in with.
+CREATE TABLE user_identities (
+    provider TEXT NOT NULL,
+    -- the provider's "sub" claim
+    subject TEXT NOT NULL,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    -- the verified email the provider reported when the identity was linked
+    email TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    last_login_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (provider, subject)
+);
+
+CREATE INDEX user_identities_user_id_idx ON user_identities (user_id);
+
+-- +goose Down
+DROP TABLE user_identities;
@@ -0,0 +1,46 @@
+-- +goose Up
+CREATE TABLE chirp_likes (
+    chirp_id UUID NOT NULL REFERENCES chirps (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (chirp_id, user_id)
+);
+
+CREATE INDEX chirp_likes_user_id_idx ON chirp_likes (user_id);
+
+-- kept up to date by the trigger below, so listings can sort by it without
+-- counting likes
+ALTER TABLE chirps
+ADD COLUMN like_count INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX chirps_like_count_idx ON chirps (like_count DESC, created_at DESC, id DESC);
+
+-- +goose StatementBegin
+CREATE FUNCTION count_chirp_likes() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        UPDATE chirps SET like_count = like_count + 1 WHERE id = NEW.chirp_id;
+    ELSE
+        UPDATE chirps SET like_count = like_count - 1 WHERE id = OLD.chirp_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- also fires for likes removed by a cascade, such as when a user is purged
+CREATE TRIGGER chirp_likes_count
+AFTER INSERT OR DELETE ON chirp_likes
+FOR EACH ROW EXECUTE FUNCTION count_chirp_likes();
+
+-- +goose Down
+DROP TRIGGER chirp_likes_count ON chirp_likes;
+
+DROP FUNCTION count_chirp_likes;
+
+DROP INDEX chirps_like_count_idx;
+
+ALTER TABLE chirps
+DROP COLUMN like_count;
+
+DROP TABLE chirp_likes;
@@ -0,0 +1,54 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN reply_to_id UUID REFERENCES chirps (id) ON DELETE SET NULL,
+-- deleted chirps with replies are kept, without their body, so their
+-- thread stays whole
+ADD COLUMN deleted_at TIMESTAMP,
+-- kept up to date by the trigger below
+ADD COLUMN reply_count INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX chirps_reply_to_id_idx ON chirps (reply_to_id);
+
+-- the bodies of deleted chirps are all empty
+ALTER TABLE chirps
+DROP CONSTRAINT chirps_body_key;
+
+CREATE UNIQUE INDEX chirps_body_key ON chirps (body) WHERE deleted_at IS NULL;
+
+-- +goose StatementBegin
+CREATE FUNCTION count_chirp_replies() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' AND OLD.reply_to_id IS NOT NULL AND OLD.deleted_at IS NULL THEN
+        UPDATE chirps SET reply_count = reply_count - 1 WHERE id = OLD.reply_to_id;
+    END IF;
+    IF TG_OP <> 'DELETE' AND NEW.reply_to_id IS NOT NULL AND NEW.deleted_at IS NULL THEN
+        UPDATE chirps SET reply_count = reply_count + 1 WHERE id = NEW.reply_to_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- deleted replies don't count
+CREATE TRIGGER chirps_reply_count
+AFTER INSERT OR DELETE OR UPDATE OF reply_to_id, deleted_at ON chirps
+FOR EACH ROW EXECUTE FUNCTION count_chirp_replies();
+
+-- +goose Down
+DROP TRIGGER chirps_reply_count ON chirps;
+
+DROP FUNCTION count_chirp_replies;
+
+DELETE FROM chirps WHERE deleted_at IS NOT NULL;
+
+DROP INDEX chirps_body_key;
+
+ALTER TABLE chirps
+ADD CONSTRAINT chirps_body_key UNIQUE (body);
+
+DROP INDEX chirps_reply_to_id_idx;
+
+ALTER TABLE chirps
+DROP COLUMN reply_count,
+DROP COLUMN deleted_at,
+DROP COLUMN reply_to_id;